@@ -0,0 +1,212 @@
+package main
+
+import (
+	"cmp"
+	"container/heap"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Comparator orders two values: negative when a comes first, zero when they
+// are equal and positive when b comes first.
+type Comparator[T any] func(a, b T) int
+
+// Collator compares strings according to the rules of a language.
+type Collator interface {
+	Compare(a, b string) int
+}
+
+// alphabetCollator sorts letters in the order of a national alphabet,
+// ignoring case and apostrophes first and using them only to break ties.
+type alphabetCollator struct {
+	rank map[rune]rune
+}
+
+const ukrainianAlphabet = "абвгґдеєжзиіїйклмнопрстуфхцчшщьюя"
+
+var UkrainianCollator Collator = NewAlphabetCollator(ukrainianAlphabet)
+
+// NewAlphabetCollator builds a collator from the lower-case letters of an
+// alphabet in their dictionary order. Letters outside the alphabet keep their
+// code point order; the alphabet is placed at the start of the Cyrillic block
+// so Latin letters sort before it and other Cyrillic letters after it.
+func NewAlphabetCollator(alphabet string) Collator {
+	c := alphabetCollator{rank: make(map[rune]rune)}
+	for i, r := range []rune(alphabet) {
+		c.rank[r] = 0x400 + rune(i)
+	}
+	return c
+}
+
+func (c alphabetCollator) Compare(a, b string) int {
+	if primary := slicesCompare(c.key(a), c.key(b)); primary != 0 {
+		return primary
+	}
+	// Lower case sorts before upper case when the letters are the same.
+	if byCase := strings.Compare(b, a); byCase != 0 && strings.EqualFold(a, b) {
+		return byCase
+	}
+	return strings.Compare(a, b)
+}
+
+func (c alphabetCollator) key(s string) []rune {
+	key := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r == '\'' || r == '’' || r == 'ʼ' {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if rank, ok := c.rank[r]; ok {
+			r = rank
+		}
+		key = append(key, r)
+	}
+	return key
+}
+
+func slicesCompare(a, b []rune) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return cmp.Compare(a[i], b[i])
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func By[T any, K cmp.Ordered](key func(T) K) Comparator[T] {
+	return func(a, b T) int { return cmp.Compare(key(a), key(b)) }
+}
+
+// ByCollated compares a string key with a language-aware collator.
+func ByCollated[T any](key func(T) string, c Collator) Comparator[T] {
+	return func(a, b T) int { return c.Compare(key(a), key(b)) }
+}
+
+// ThenBy breaks ties of c with next.
+func (c Comparator[T]) ThenBy(next Comparator[T]) Comparator[T] {
+	return func(a, b T) int {
+		if r := c(a, b); r != 0 {
+			return r
+		}
+		return next(a, b)
+	}
+}
+
+func (c Comparator[T]) Reversed() Comparator[T] {
+	return func(a, b T) int { return c(b, a) }
+}
+
+// Less adapts the comparator to the less functions taken by Max and Sort.
+func (c Comparator[T]) Less(a, b T) bool {
+	return c(a, b) < 0
+}
+
+// NullsFirst orders nil pointers before all other values.
+func NullsFirst[T any](c Comparator[T]) Comparator[*T] {
+	return nullsAt[T](c, -1)
+}
+
+// NullsLast orders nil pointers after all other values.
+func NullsLast[T any](c Comparator[T]) Comparator[*T] {
+	return nullsAt[T](c, 1)
+}
+
+func nullsAt[T any](c Comparator[T], nilOrder int) Comparator[*T] {
+	return func(a, b *T) int {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return nilOrder
+		case b == nil:
+			return -nilOrder
+		}
+		return c(*a, *b)
+	}
+}
+
+func (s Stream[T]) MaxBy(c Comparator[T]) *T {
+	return s.Max(c.Less)
+}
+
+func (s Stream[T]) Min(less func(T, T) bool) *T {
+	start := time.Now()
+	if len(s.elements) == 0 {
+		s.finish("Min", start, 0, "")
+		return nil
+	}
+	min := s.elements[0]
+	for _, e := range s.elements[1:] {
+		if less(e, min) {
+			min = e
+		}
+	}
+	s.finish("Min", start, 1, min.display())
+	return &min
+}
+
+func (s Stream[T]) MinBy(c Comparator[T]) *T {
+	return s.Min(c.Less)
+}
+
+func (s Stream[T]) SortBy(c Comparator[T]) Stream[T] {
+	return s.Sort(c.Less)
+}
+
+// TopK returns the first k elements in comparator order without sorting the
+// whole stream; use a reversed comparator to get the k largest elements.
+func (s Stream[T]) TopK(k int, c Comparator[T]) Stream[T] {
+	start := time.Now()
+	if k <= 0 {
+		return s.next("TopK", start, len(s.elements), nil)
+	}
+
+	// A max-heap of the k best elements seen so far: the root is the worst
+	// of them and is replaced whenever a better element arrives.
+	h := &boundedHeap[T]{cmp: c.Reversed()}
+	for i, e := range s.elements {
+		switch {
+		case h.Len() < k:
+			heap.Push(h, indexed[T]{e, i})
+		case h.cmp.ordered(h.items[0], indexed[T]{e, i}):
+			h.items[0] = indexed[T]{e, i}
+			heap.Fix(h, 0)
+		}
+	}
+
+	top := make([]T, h.Len())
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(h).(indexed[T]).value
+	}
+	return s.next("TopK", start, len(s.elements), top)
+}
+
+// indexed remembers the input position so TopK is stable like Sort.
+type indexed[T any] struct {
+	value T
+	index int
+}
+
+type boundedHeap[T any] struct {
+	items []indexed[T]
+	cmp   Comparator[T]
+}
+
+// ordered reports whether a comes before b in heap order.
+func (c Comparator[T]) ordered(a, b indexed[T]) bool {
+	if r := c(a.value, b.value); r != 0 {
+		return r < 0
+	}
+	return a.index > b.index
+}
+
+func (h *boundedHeap[T]) Len() int           { return len(h.items) }
+func (h *boundedHeap[T]) Less(i, j int) bool { return h.cmp.ordered(h.items[i], h.items[j]) }
+func (h *boundedHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *boundedHeap[T]) Push(x any)         { h.items = append(h.items, x.(indexed[T])) }
+func (h *boundedHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
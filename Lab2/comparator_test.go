@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestUkrainianCollator(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"alphabet", []string{"г", "ґ", "е", "є", "и", "і", "ї", "й"}},
+		{"apostrophe ignored", []string{"пам'ять", "пясток", "п'ять"}},
+		{"apostrophes break ties", []string{"м'ята", "мята", "м’ятий"}},
+		{"lower case first", []string{"ірина", "Ірина", "іскра"}},
+		{"latin before cyrillic", []string{"Zebra", "абрикос", "ёлка"}},
+	}
+	for _, tt := range tests {
+		got := slices.Clone(tt.want)
+		rand.New(rand.NewPCG(1, 2)).Shuffle(len(got), func(i, j int) { got[i], got[j] = got[j], got[i] })
+		slices.SortFunc(got, UkrainianCollator.Compare)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNulls(t *testing.T) {
+	one, two := 1, 2
+	values := []*int{&two, nil, &one, nil}
+	tests := []struct {
+		name string
+		cmp  Comparator[*int]
+		want []*int
+	}{
+		{"first", NullsFirst(By(func(n int) int { return n })), []*int{nil, nil, &one, &two}},
+		{"last", NullsLast(By(func(n int) int { return n })), []*int{&one, &two, nil, nil}},
+		{"first reversed", NullsFirst(By(func(n int) int { return n }).Reversed()), []*int{nil, nil, &two, &one}},
+	}
+	for _, tt := range tests {
+		got := slices.Clone(values)
+		slices.SortStableFunc(got, tt.cmp)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTopKMatchesSortAndLimit(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	doctors := make([]Doctor, 50)
+	for i := range doctors {
+		// Few distinct salaries, so ties must keep the input order.
+		doctors[i] = Doctor{ID: i, Name: string(rune('А' + i%32)), Salary: 1000 * rng.IntN(5)}
+	}
+	comparators := map[string]Comparator[Doctor]{
+		"salary":           By(func(d Doctor) int { return d.Salary }),
+		"salary reversed":  By(func(d Doctor) int { return d.Salary }).Reversed(),
+		"name then salary": ByCollated(func(d Doctor) string { return d.Name }, UkrainianCollator).ThenBy(By(func(d Doctor) int { return d.Salary })),
+	}
+	for name, c := range comparators {
+		for _, k := range []int{0, 1, 5, 49, 50, 51} {
+			s := CreateStream(doctors)
+			got := s.TopK(k, c).ToSlice()
+			want := s.SortBy(c).Limit(k).ToSlice()
+			if !slices.Equal(got, want) {
+				t.Errorf("%s, k %d: got %v, want %v", name, k, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "fmt"
+
+type DoctorNotFoundError struct {
+	Name string
+}
+
+type PatientNotFoundError struct {
+	Name string
+}
+
+type DuplicateDoctorError struct {
+	Name string
+}
+
+type DuplicatePatientError struct {
+	Name string
+}
+
+type CapacityError struct {
+	Hospital string
+	Capacity int
+}
+
+type DoctorLoadError struct {
+	Doctor string
+	Limit  int
+}
+
+type DoctorHasPatientsError struct {
+	Doctor   string
+	Patients int
+}
+
+// InvariantError describes one broken rule found by CheckInvariants.
+type InvariantError struct {
+	Rule   string
+	Detail string
+}
+
+func (e *DoctorNotFoundError) Error() string {
+	return fmt.Sprintf("doctor %q does not work in this hospital", e.Name)
+}
+
+func (e *PatientNotFoundError) Error() string {
+	return fmt.Sprintf("patient %q is not admitted", e.Name)
+}
+
+func (e *DuplicateDoctorError) Error() string {
+	return fmt.Sprintf("doctor %q is already hired", e.Name)
+}
+
+func (e *DuplicatePatientError) Error() string {
+	return fmt.Sprintf("patient %q is already admitted", e.Name)
+}
+
+func (e *CapacityError) Error() string {
+	return fmt.Sprintf("hospital %q is full (capacity %d)", e.Hospital, e.Capacity)
+}
+
+func (e *DoctorLoadError) Error() string {
+	return fmt.Sprintf("doctor %q already has the maximum of %d patients", e.Doctor, e.Limit)
+}
+
+func (e *DoctorHasPatientsError) Error() string {
+	return fmt.Sprintf("doctor %q still has %d patients", e.Doctor, e.Patients)
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invariant %s violated: %s", e.Rule, e.Detail)
+}
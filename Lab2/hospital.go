@@ -1,25 +1,30 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 )
 
 type Doctor struct {
-	Name   string
-	Salary int
+	Name   string `render:"name,1"`
+	Salary int    `render:"salary,2"`
+	ID     int    `render:"id,3"`
 }
 
 type Patient struct {
-	Name   string
-	Age    int
-	Doctor Doctor
+	Name   string `render:"name,1"`
+	Age    int    `render:"age,2"`
+	Doctor Doctor `render:"doctor,3"`
 }
 
+// Hospital limits are disabled when set to zero.
 type Hospital struct {
-	Name     string
-	Location string
-	Patients []Patient
-	Doctors  []Doctor
+	Name                 string    `render:"name,1"`
+	Location             string    `render:"location,2"`
+	Patients             []Patient `render:"patients,4"`
+	Doctors              []Doctor  `render:"doctors,3"`
+	Capacity             int       `render:"capacity,5"`
+	MaxPatientsPerDoctor int       `render:"max_patients_per_doctor,6"`
 }
 
 func (d Doctor) display() string {
@@ -32,4 +37,138 @@ func (p Patient) display() string {
 
 func (h Hospital) display() string {
 	return fmt.Sprintf("Hospital: %s, Location: %s", h.Name, h.Location)
-}
\ No newline at end of file
+}
+
+func (h *Hospital) Hire(d Doctor) error {
+	if _, ok := h.findDoctor(d.Name); ok {
+		return &DuplicateDoctorError{Name: d.Name}
+	}
+	h.Doctors = append(h.Doctors, d)
+	return nil
+}
+
+func (h *Hospital) Dismiss(name string) error {
+	i, ok := h.findDoctor(name)
+	if !ok {
+		return &DoctorNotFoundError{Name: name}
+	}
+	if load := h.PatientsOf(name).Count(); load > 0 {
+		return &DoctorHasPatientsError{Doctor: name, Patients: load}
+	}
+	h.Doctors = append(h.Doctors[:i], h.Doctors[i+1:]...)
+	return nil
+}
+
+// Admit registers a patient with the doctor named in p.Doctor, which must
+// already be on staff; the patient gets the staff record of that doctor.
+func (h *Hospital) Admit(p Patient) error {
+	if _, ok := h.findPatient(p.Name); ok {
+		return &DuplicatePatientError{Name: p.Name}
+	}
+	if h.Capacity > 0 && len(h.Patients) >= h.Capacity {
+		return &CapacityError{Hospital: h.Name, Capacity: h.Capacity}
+	}
+	doctor, err := h.assignableDoctor(p.Doctor.Name, "")
+	if err != nil {
+		return err
+	}
+	p.Doctor = doctor
+	h.Patients = append(h.Patients, p)
+	return nil
+}
+
+func (h *Hospital) Discharge(name string) (Patient, error) {
+	i, ok := h.findPatient(name)
+	if !ok {
+		return Patient{}, &PatientNotFoundError{Name: name}
+	}
+	patient := h.Patients[i]
+	h.Patients = append(h.Patients[:i], h.Patients[i+1:]...)
+	return patient, nil
+}
+
+// Reassign moves a patient to another doctor on staff. Reassigning to the
+// current doctor checks the same rules and refreshes the doctor record.
+func (h *Hospital) Reassign(patientName, doctorName string) error {
+	i, ok := h.findPatient(patientName)
+	if !ok {
+		return &PatientNotFoundError{Name: patientName}
+	}
+	doctor, err := h.assignableDoctor(doctorName, patientName)
+	if err != nil {
+		return err
+	}
+	h.Patients[i].Doctor = doctor
+	return nil
+}
+
+func (h Hospital) PatientsOf(doctorName string) Stream[Patient] {
+	return CreateStream(h.Patients).Filter(func(p Patient) bool { return p.Doctor.Name == doctorName })
+}
+
+// CheckInvariants returns every violated rule joined into one error, or nil.
+func (h Hospital) CheckInvariants() error {
+	var errs []error
+	seenDoctors := make(map[string]bool)
+	for _, d := range h.Doctors {
+		if seenDoctors[d.Name] {
+			errs = append(errs, &InvariantError{Rule: "unique doctors", Detail: fmt.Sprintf("doctor %q is listed twice", d.Name)})
+		}
+		seenDoctors[d.Name] = true
+		if load := h.PatientsOf(d.Name).Count(); h.MaxPatientsPerDoctor > 0 && load > h.MaxPatientsPerDoctor {
+			errs = append(errs, &InvariantError{Rule: "doctor load", Detail: fmt.Sprintf("doctor %q has %d patients, limit is %d", d.Name, load, h.MaxPatientsPerDoctor)})
+		}
+	}
+
+	seenPatients := make(map[string]bool)
+	for _, p := range h.Patients {
+		if seenPatients[p.Name] {
+			errs = append(errs, &InvariantError{Rule: "unique patients", Detail: fmt.Sprintf("patient %q is listed twice", p.Name)})
+		}
+		seenPatients[p.Name] = true
+		i, ok := h.findDoctor(p.Doctor.Name)
+		if !ok {
+			errs = append(errs, &InvariantError{Rule: "assigned doctor on staff", Detail: fmt.Sprintf("patient %q is assigned to unknown doctor %q", p.Name, p.Doctor.Name)})
+		} else if h.Doctors[i] != p.Doctor {
+			errs = append(errs, &InvariantError{Rule: "doctor record in sync", Detail: fmt.Sprintf("patient %q holds a stale record of doctor %q", p.Name, p.Doctor.Name)})
+		}
+	}
+
+	if h.Capacity > 0 && len(h.Patients) > h.Capacity {
+		errs = append(errs, &InvariantError{Rule: "capacity", Detail: fmt.Sprintf("%d patients admitted, capacity is %d", len(h.Patients), h.Capacity)})
+	}
+	return errors.Join(errs...)
+}
+
+// assignableDoctor returns the staff record of the doctor if they can take
+// one more patient; moving, if set, is a patient who does not count towards
+// the load, as they are being reassigned.
+func (h Hospital) assignableDoctor(name, moving string) (Doctor, error) {
+	i, ok := h.findDoctor(name)
+	if !ok {
+		return Doctor{}, &DoctorNotFoundError{Name: name}
+	}
+	load := h.PatientsOf(name).Filter(func(p Patient) bool { return p.Name != moving }).Count()
+	if h.MaxPatientsPerDoctor > 0 && load >= h.MaxPatientsPerDoctor {
+		return Doctor{}, &DoctorLoadError{Doctor: name, Limit: h.MaxPatientsPerDoctor}
+	}
+	return h.Doctors[i], nil
+}
+
+func (h Hospital) findDoctor(name string) (int, bool) {
+	for i, d := range h.Doctors {
+		if d.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (h Hospital) findPatient(name string) (int, bool) {
+	for i, p := range h.Patients {
+		if p.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// testHospital has two doctors; Smith has reached the limit of two
+// patients.
+func testHospital() *Hospital {
+	h := &Hospital{Name: "City Hospital", Capacity: 3, MaxPatientsPerDoctor: 2}
+	h.Hire(Doctor{Name: "Smith", Salary: 5000})
+	h.Hire(Doctor{Name: "Brown", Salary: 4500})
+	h.Admit(Patient{Name: "Alice", Doctor: Doctor{Name: "Smith"}})
+	h.Admit(Patient{Name: "Bob", Doctor: Doctor{Name: "Smith"}})
+	return h
+}
+
+func TestHospitalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(h *Hospital) error
+		wantErr any
+	}{
+		{"admit", func(h *Hospital) error { return h.Admit(Patient{Name: "Carol", Doctor: Doctor{Name: "Brown"}}) }, nil},
+		{"admit twice", func(h *Hospital) error { return h.Admit(Patient{Name: "Alice", Doctor: Doctor{Name: "Brown"}}) }, new(*DuplicatePatientError)},
+		{"admit to unknown doctor", func(h *Hospital) error { return h.Admit(Patient{Name: "Carol", Doctor: Doctor{Name: "Who"}}) }, new(*DoctorNotFoundError)},
+		{"admit to full doctor", func(h *Hospital) error { return h.Admit(Patient{Name: "Carol", Doctor: Doctor{Name: "Smith"}}) }, new(*DoctorLoadError)},
+		{"admit to full hospital", func(h *Hospital) error {
+			h.Admit(Patient{Name: "Carol", Doctor: Doctor{Name: "Brown"}})
+			return h.Admit(Patient{Name: "Dave", Doctor: Doctor{Name: "Brown"}})
+		}, new(*CapacityError)},
+		{"discharge", func(h *Hospital) error { _, err := h.Discharge("Alice"); return err }, nil},
+		{"discharge unknown", func(h *Hospital) error { _, err := h.Discharge("Carol"); return err }, new(*PatientNotFoundError)},
+		{"reassign", func(h *Hospital) error { return h.Reassign("Alice", "Brown") }, nil},
+		{"reassign unknown patient", func(h *Hospital) error { return h.Reassign("Carol", "Brown") }, new(*PatientNotFoundError)},
+		{"reassign to unknown doctor", func(h *Hospital) error { return h.Reassign("Alice", "Who") }, new(*DoctorNotFoundError)},
+		{"reassign to full doctor", func(h *Hospital) error {
+			h.Admit(Patient{Name: "Carol", Doctor: Doctor{Name: "Brown"}})
+			return h.Reassign("Carol", "Smith")
+		}, new(*DoctorLoadError)},
+		// The patient already counts towards the load of their doctor.
+		{"reassign to the same full doctor", func(h *Hospital) error { return h.Reassign("Alice", "Smith") }, nil},
+		{"reassign to the same dismissed doctor", func(h *Hospital) error {
+			h.Doctors = h.Doctors[1:]
+			return h.Reassign("Alice", "Smith")
+		}, new(*DoctorNotFoundError)},
+	}
+	for _, tt := range tests {
+		err := tt.change(testHospital())
+		switch {
+		case tt.wantErr == nil && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.wantErr != nil && !errors.As(err, tt.wantErr):
+			t.Errorf("%s: got %v, want %T", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReassignRefreshesDoctorRecord(t *testing.T) {
+	h := testHospital()
+	h.Doctors[0].Salary = 5500
+	if err := h.Reassign("Alice", "Smith"); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Patients[0].Doctor.Salary; got != 5500 {
+		t.Errorf("patient holds salary %d, want the staff record 5500", got)
+	}
+}
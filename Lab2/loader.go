@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadError reports a problem with one record of a data file.
+type LoadError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+type doctorRecord struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Salary int    `json:"salary"`
+	line   int
+}
+
+// patientRecord refers to its doctor by name, by ID or by both.
+type patientRecord struct {
+	Name     string `json:"name"`
+	Age      int    `json:"age"`
+	Doctor   string `json:"doctor"`
+	DoctorID int    `json:"doctor_id"`
+	line     int
+}
+
+// LoadHospitalJSON reads a hospital with its doctors and patients from a
+// JSON document:
+//
+//	{"name": "...", "location": "...", "capacity": 10, "max_patients_per_doctor": 3,
+//	 "doctors": [{"id": 1, "name": "Dr. Smith", "salary": 5000}],
+//	 "patients": [{"name": "Alice", "age": 30, "doctor": "Dr. Smith"}, {"name": "Bob", "age": 45, "doctor_id": 1}]}
+//
+// All invalid records and header fields are reported together, each with its
+// line number. A syntax error stops the reading, as the rest of the document
+// cannot be parsed reliably.
+func LoadHospitalJSON(path string) (Hospital, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Hospital{}, err
+	}
+
+	var h Hospital
+	headerFields := map[string]any{
+		"name":                    &h.Name,
+		"location":                &h.Location,
+		"capacity":                &h.Capacity,
+		"max_patients_per_doctor": &h.MaxPatientsPerDoctor,
+	}
+	var doctors []doctorRecord
+	var patients []patientRecord
+	dec := json.NewDecoder(bytes.NewReader(data))
+	fail := func(offset int64, err error) (Hospital, error) {
+		return Hospital{}, &LoadError{File: path, Line: lineAt(data, offset), Err: err}
+	}
+	var errs []error
+	invalid := func(offset int64, err error) {
+		errs = append(errs, &LoadError{File: path, Line: lineAt(data, offset), Err: err})
+	}
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fail(dec.InputOffset(), errors.New("expected a JSON object"))
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fail(dec.InputOffset(), err)
+		}
+		key := tok.(string)
+
+		switch key {
+		case "doctors", "patients":
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				return fail(dec.InputOffset(), fmt.Errorf("%q must be an array", key))
+			}
+			for dec.More() {
+				start := recordStart(data, dec.InputOffset())
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return fail(start, err)
+				}
+				if key == "doctors" {
+					r := doctorRecord{line: lineAt(data, start)}
+					if err := decodeStrict(raw, &r); err != nil {
+						invalid(start+jsonErrorOffset(err), err)
+						continue
+					}
+					doctors = append(doctors, r)
+				} else {
+					r := patientRecord{line: lineAt(data, start)}
+					if err := decodeStrict(raw, &r); err != nil {
+						invalid(start+jsonErrorOffset(err), err)
+						continue
+					}
+					patients = append(patients, r)
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return fail(dec.InputOffset(), err)
+			}
+		default:
+			start := recordStart(data, dec.InputOffset())
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return fail(start, err)
+			}
+			target, ok := headerFields[key]
+			if !ok {
+				invalid(start, fmt.Errorf("unknown field %q", key))
+				continue
+			}
+			if err := json.Unmarshal(raw, target); err != nil {
+				invalid(start+jsonErrorOffset(err), fmt.Errorf("field %q: %w", key, err))
+			}
+		}
+	}
+
+	if err := buildHospital(&h, path, path, doctors, patients); err != nil {
+		errs = append(errs, err)
+	}
+	return h, errors.Join(errs...)
+}
+
+// LoadHospitalCSV fills base with doctors and patients read from two CSV
+// files with headers. Doctors need name and salary columns and may have an
+// id column; patients need name, age and a doctor or doctor_id column.
+// Both files are read before their errors are reported together.
+func LoadHospitalCSV(base Hospital, doctorsPath, patientsPath string) (Hospital, error) {
+	var doctors []doctorRecord
+	doctorsErr := readCSV(doctorsPath, []string{"name", "salary"}, func(row csvRow) error {
+		id, err := row.optionalInt("id")
+		if err != nil {
+			return err
+		}
+		salary, err := row.int("salary")
+		if err != nil {
+			return err
+		}
+		doctors = append(doctors, doctorRecord{ID: id, Name: row.get("name"), Salary: salary, line: row.line})
+		return nil
+	})
+
+	var patients []patientRecord
+	patientsErr := readCSV(patientsPath, []string{"name", "age"}, func(row csvRow) error {
+		age, err := row.int("age")
+		if err != nil {
+			return err
+		}
+		doctorID, err := row.optionalInt("doctor_id")
+		if err != nil {
+			return err
+		}
+		patients = append(patients, patientRecord{Name: row.get("name"), Age: age, Doctor: row.get("doctor"), DoctorID: doctorID, line: row.line})
+		return nil
+	})
+	if err := errors.Join(doctorsErr, patientsErr); err != nil {
+		return Hospital{}, err
+	}
+
+	h := base
+	h.Doctors, h.Patients = nil, nil
+	return h, buildHospital(&h, doctorsPath, patientsPath, doctors, patients)
+}
+
+// buildHospital validates the records and adds them through Hire and Admit,
+// so the loaded hospital satisfies the same rules as one built in code.
+func buildHospital(h *Hospital, doctorsPath, patientsPath string, doctors []doctorRecord, patients []patientRecord) error {
+	var errs []error
+	byID := make(map[int]string)
+
+	for _, r := range doctors {
+		fail := func(err error) { errs = append(errs, &LoadError{File: doctorsPath, Line: r.line, Err: err}) }
+		switch {
+		case strings.TrimSpace(r.Name) == "":
+			fail(errors.New("doctor name is empty"))
+			continue
+		case r.Salary < 0:
+			fail(fmt.Errorf("doctor %q has a negative salary", r.Name))
+			continue
+		}
+		if r.ID != 0 {
+			if other, ok := byID[r.ID]; ok {
+				fail(fmt.Errorf("doctor id %d is already used by %q", r.ID, other))
+				continue
+			}
+		}
+		if err := h.Hire(Doctor{ID: r.ID, Name: r.Name, Salary: r.Salary}); err != nil {
+			fail(err)
+			continue
+		}
+		if r.ID != 0 {
+			byID[r.ID] = r.Name
+		}
+	}
+
+	for _, r := range patients {
+		fail := func(err error) { errs = append(errs, &LoadError{File: patientsPath, Line: r.line, Err: err}) }
+		switch {
+		case strings.TrimSpace(r.Name) == "":
+			fail(errors.New("patient name is empty"))
+			continue
+		case r.Age < 0 || r.Age > 150:
+			fail(fmt.Errorf("patient %q has an invalid age %d", r.Name, r.Age))
+			continue
+		}
+
+		doctorName := r.Doctor
+		if r.DoctorID != 0 {
+			name, ok := byID[r.DoctorID]
+			switch {
+			case !ok:
+				fail(fmt.Errorf("patient %q refers to unknown doctor id %d", r.Name, r.DoctorID))
+				continue
+			case doctorName != "" && doctorName != name:
+				fail(fmt.Errorf("patient %q refers to doctor id %d (%q) and doctor %q", r.Name, r.DoctorID, name, doctorName))
+				continue
+			}
+			doctorName = name
+		}
+		if doctorName == "" {
+			fail(fmt.Errorf("patient %q has no doctor", r.Name))
+			continue
+		}
+		if err := h.Admit(Patient{Name: r.Name, Age: r.Age, Doctor: Doctor{Name: doctorName}}); err != nil {
+			fail(err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type csvRow struct {
+	line    int
+	columns map[string]int
+	record  []string
+}
+
+func (r csvRow) get(column string) string {
+	if i, ok := r.columns[column]; ok {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+func (r csvRow) int(column string) (int, error) {
+	n, err := strconv.Atoi(r.get(column))
+	if err != nil {
+		return 0, fmt.Errorf("column %q: %q is not a whole number", column, r.get(column))
+	}
+	return n, nil
+}
+
+func (r csvRow) optionalInt(column string) (int, error) {
+	if r.get(column) == "" {
+		return 0, nil
+	}
+	return r.int(column)
+}
+
+// readCSV calls handle for every data row; row errors are collected and
+// returned together, annotated with the file and line.
+func readCSV(path string, required []string, handle func(csvRow) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("file is empty, expected a header row")
+		}
+		return &LoadError{File: path, Line: 1, Err: err}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return &LoadError{File: path, Line: 1, Err: fmt.Errorf("missing column %q", name)}
+		}
+	}
+
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, &LoadError{File: path, Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		if err := handle(csvRow{line: line, columns: columns, record: record}); err != nil {
+			errs = append(errs, &LoadError{File: path, Line: line, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// recordStart skips the separator between the previous token and the next value.
+func recordStart(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func jsonErrorOffset(err error) int64 {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		return typeErr.Offset
+	}
+	return 0
+}
+
+func lineAt(data []byte, offset int64) int {
+	offset = min(max(offset, 0), int64(len(data)))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadHospitalJSONReportsAllRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hospital.json")
+	data := `{
+  "name": "City Hospital",
+  "capacity": "ten",
+  "doctors": [
+    {"id": 1, "name": "Dr. Smith", "salary": 5000},
+    {"id": 2, "name": "Dr. Who", "salary": "lots"},
+    {"id": 3, "name": "Dr. Brown", "salary": 4500, "room": 12}
+  ],
+  "patients": [
+    {"name": "Alice", "age": 30, "doctor": "Dr. Smith"},
+    {"name": "Bob", "age": -1, "doctor_id": 1},
+    {"name": "Carol", "age": "old", "doctor_id": 1}
+  ],
+  "wing": "east"
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := LoadHospitalJSON(path)
+	want := map[int]string{
+		3:  `field "capacity"`,
+		6:  "salary",
+		7:  `unknown field "room"`,
+		11: "invalid age -1",
+		12: "age",
+		14: `unknown field "wing"`,
+	}
+	var lines []int
+	collectLoadErrors(err, &lines, func(e *LoadError) {
+		if msg, ok := want[e.Line]; !ok || !strings.Contains(e.Error(), msg) {
+			t.Errorf("unexpected error %v", e)
+		}
+	})
+	if len(lines) != len(want) {
+		t.Errorf("got errors on lines %v, want %d errors:\n%v", lines, len(want), err)
+	}
+	if len(h.Doctors) != 1 || len(h.Patients) != 1 {
+		t.Errorf("loaded %d doctors and %d patients, want the valid 1 and 1", len(h.Doctors), len(h.Patients))
+	}
+}
+
+func TestLoadHospitalCSVReportsBothFiles(t *testing.T) {
+	dir := t.TempDir()
+	doctorsPath := filepath.Join(dir, "doctors.csv")
+	patientsPath := filepath.Join(dir, "patients.csv")
+	if err := os.WriteFile(doctorsPath, []byte("id,name,salary\n1,Dr. Smith,5000\n2,Dr. Who,lots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(patientsPath, []byte("name,age,doctor_id\nAlice,30,1\nBob,old,1\nCarol,25,x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadHospitalCSV(Hospital{Capacity: 10}, doctorsPath, patientsPath)
+	want := map[string][]int{doctorsPath: {3}, patientsPath: {3, 4}}
+	got := map[string][]int{}
+	var lines []int
+	collectLoadErrors(err, &lines, func(e *LoadError) {
+		got[e.File] = append(got[e.File], e.Line)
+	})
+	for file, lines := range want {
+		if !slices.Equal(got[file], lines) {
+			t.Errorf("%s: got errors on lines %v, want %v:\n%v", file, got[file], lines, err)
+		}
+	}
+}
+
+func TestLoadHospitalJSONSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hospital.json")
+	if err := os.WriteFile(path, []byte("{\n  \"doctors\": [\n    {\"name\": \"A\",}\n  ]\n}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadHospitalJSON(path)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Line != 3 {
+		t.Errorf("got %v, want a syntax error on line 3", err)
+	}
+}
+
+// collectLoadErrors calls f for every LoadError in a tree of joined errors.
+func collectLoadErrors(err error, lines *[]int, f func(*LoadError)) {
+	switch e := err.(type) {
+	case *LoadError:
+		*lines = append(*lines, e.Line)
+		f(e)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			collectLoadErrors(inner, lines, f)
+		}
+	}
+}
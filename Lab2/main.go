@@ -1,14 +1,21 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 )
 
 func main() {
+	hospitalFile := flag.String("hospital", "", "JSON file with the hospital, its doctors and patients")
+	doctorsFile := flag.String("doctors", "", "CSV file with doctors (used with -patients)")
+	patientsFile := flag.String("patients", "", "CSV file with patients (used with -doctors)")
+	flag.Parse()
+
 	doctors := []Doctor{
-		{Name: "Dr. Smith", Salary: 5000},
-		{Name: "Dr. Johnson", Salary: 6000},
-		{Name: "Dr. Brown", Salary: 4500},
+		{ID: 1, Name: "Dr. Smith", Salary: 5000},
+		{ID: 2, Name: "Dr. Johnson", Salary: 6000},
+		{ID: 3, Name: "Dr. Brown", Salary: 4500},
 	}
 
 	patients := []Patient{
@@ -17,6 +24,37 @@ func main() {
 		{Name: "Charlie", Age: 25, Doctor: doctors[0]},
 	}
 
+	if flag.NArg() > 0 {
+		hospital := Hospital{Name: "City Hospital", Location: "Kyiv", Doctors: doctors, Patients: patients}
+		var err error
+		switch {
+		case *hospitalFile != "":
+			hospital, err = LoadHospitalJSON(*hospitalFile)
+		case *doctorsFile != "" && *patientsFile != "":
+			hospital, err = LoadHospitalCSV(Hospital{Name: "City Hospital", Location: "Kyiv"}, *doctorsFile, *patientsFile)
+		case *doctorsFile != "" || *patientsFile != "":
+			err = fmt.Errorf("-doctors and -patients must be used together")
+		}
+		if err != nil {
+			fmt.Println("Error loading hospital:", err)
+			os.Exit(1)
+		}
+
+		switch flag.Arg(0) {
+		case "repl":
+			runREPL(hospital, os.Stdin, os.Stdout)
+		case "report":
+			if err := runReport(hospital, flag.Args()[1:], os.Stdout); err != nil {
+				fmt.Println("Error building report:", err)
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("Unknown command %q, expected repl or report\n", flag.Arg(0))
+			os.Exit(2)
+		}
+		return
+	}
+
 	doctorStream := CreateStream(doctors)
 	doctorStream.
 		Filter(func(d Doctor) bool { return d.Salary > 4500 }).
@@ -33,6 +71,103 @@ func main() {
 		fmt.Println("Doctor with max salary:", maxDoctor.display())
 	}
 
+	bySalary := By(func(d Doctor) int { return d.Salary })
+	if minDoctor := doctorStream.MinBy(bySalary); minDoctor != nil {
+		fmt.Println("Doctor with min salary:", minDoctor.display())
+	}
+	doctorStream.TopK(2, bySalary.Reversed().ThenBy(By(func(d Doctor) string { return d.Name }))).Display()
+
 	totalSalary := doctorStream.Reduce(0, func(acc int, d Doctor) int { return acc + d.Salary })
 	fmt.Println("Total salary of doctors:", totalSalary)
+
+	salaryStats := doctorStream.Summarize(func(d Doctor) float64 { return float64(d.Salary) })
+	fmt.Println("Salary statistics:", salaryStats.display())
+	fmt.Printf("Salary 90th percentile: %.2f\n", salaryStats.Percentile(90))
+
+	ageHistogram := patientStream.Histogram(func(p Patient) float64 { return float64(p.Age) }, 2)
+	CreateStream(ageHistogram).Display()
+
+	if err := patientStream.Render(os.Stdout, TableRenderer{}); err != nil {
+		fmt.Println("Error rendering patients:", err)
+	}
+
+	hospital := Hospital{Name: "City Hospital", Location: "Kyiv", Capacity: 4, MaxPatientsPerDoctor: 2}
+	for _, d := range doctors {
+		if err := hospital.Hire(d); err != nil {
+			fmt.Println("Error hiring doctor:", err)
+		}
+	}
+	for _, p := range patients {
+		if err := hospital.Admit(p); err != nil {
+			fmt.Println("Error admitting patient:", err)
+		}
+	}
+	if err := hospital.Admit(Patient{Name: "Dave", Age: 52, Doctor: doctors[0]}); err != nil {
+		fmt.Println("Error admitting patient:", err)
+	}
+	if err := hospital.Reassign("Charlie", "Dr. Brown"); err != nil {
+		fmt.Println("Error reassigning patient:", err)
+	}
+	if err := hospital.Dismiss("Dr. Brown"); err != nil {
+		fmt.Println("Error dismissing doctor:", err)
+	}
+	if err := hospital.CheckInvariants(); err != nil {
+		fmt.Println("Hospital invariants violated:", err)
+	}
+	fmt.Println(hospital.display())
+	hospital.PatientsOf("Dr. Brown").Display()
+
+	admissions := NewObservable[Patient]()
+	adults := admissions.
+		Filter(func(p Patient) bool { return p.Age >= 30 }).
+		Subscribe(4, Block, func(p Patient) { fmt.Println("Admitted adult:", p.display()) }, nil)
+	batchSizes := []int{}
+	batches := Buffer(admissions, 2, 0).
+		Subscribe(4, Block, func(b []Patient) { batchSizes = append(batchSizes, len(b)) }, nil)
+	patientStream.Observe(admissions)
+	adults.Wait()
+	batches.Wait()
+	fmt.Println("Admission batch sizes:", batchSizes)
+
+	ukrainianPatients := []Patient{
+		{Name: "Ярослав", Age: 41, Doctor: doctors[0]},
+		{Name: "Ігор", Age: 33, Doctor: doctors[1]},
+		{Name: "Ґанна", Age: 29, Doctor: doctors[2]},
+		{Name: "Євген", Age: 57, Doctor: doctors[0]},
+		{Name: "Гліб", Age: 19, Doctor: doctors[1]},
+	}
+	CreateStream(ukrainianPatients).
+		SortBy(ByCollated(func(p Patient) string { return p.Name }, UkrainianCollator)).
+		Display()
+
+	feed := make(chan Patient, len(patients))
+	for _, p := range patients {
+		feed <- p
+	}
+	close(feed)
+	cached := Cache(FromChannel(feed), 100)
+	if replay, err := cached.Stream(); err == nil {
+		fmt.Println("Cached patients:", replay.Count())
+	}
+	if replay, err := cached.Stream(); err == nil {
+		fmt.Println("Total patient age:", replay.Reduce(0, func(acc int, p Patient) int { return acc + p.Age }))
+	}
+
+	branches := Tee(cached.All(), 2, 0)
+	oldest, _ := branches[0].Stream()
+	names, _ := branches[1].Stream()
+	if p := oldest.MaxBy(By(func(p Patient) int { return p.Age })); p != nil {
+		fmt.Println("Oldest patient:", p.display())
+	}
+	names.SortBy(ByCollated(func(p Patient) string { return p.Name }, UkrainianCollator)).Limit(1).Display()
+
+	trace := NewTrace()
+	CreateStream(doctors).
+		Traced(trace).
+		Filter(func(d Doctor) bool { return d.Salary > 5500 }).Named("Senior salaries").
+		Map(func(d Doctor) Doctor { d.Salary += 500; return d }).Named("Raise").
+		Peek(func(d Doctor) { fmt.Println("Raised:", d.display()) }).
+		Distinct().
+		Count()
+	trace.Print(os.Stdout)
 }
\ No newline at end of file
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Backpressure decides what Emit does when a subscriber's buffer is full.
+type Backpressure int
+
+const (
+	// Block makes the producer wait until the subscriber catches up.
+	Block Backpressure = iota
+	// Drop discards the new event.
+	Drop
+	// Latest discards the oldest buffered event to make room for the new one.
+	Latest
+)
+
+// Observable pushes events from producers to every subscriber. Each
+// subscriber receives events on its own goroutine in emission order.
+type Observable[T any] struct {
+	mu          sync.Mutex
+	subscribers []*Subscription[T]
+	closed      bool
+}
+
+type Subscription[T any] struct {
+	events   chan T
+	quit     chan struct{}
+	done     chan struct{}
+	strategy Backpressure
+	source   *Observable[T]
+	mu       sync.Mutex
+	closed   bool
+	dropped  int
+}
+
+func NewObservable[T any]() *Observable[T] {
+	return &Observable[T]{}
+}
+
+// Subscribe calls onNext for every emitted event and onComplete, if not nil,
+// once the observable is closed and the buffered events are delivered.
+func (o *Observable[T]) Subscribe(buffer int, strategy Backpressure, onNext func(T), onComplete func()) *Subscription[T] {
+	sub := &Subscription[T]{
+		events:   make(chan T, max(buffer, 1)),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		strategy: strategy,
+		source:   o,
+	}
+
+	o.mu.Lock()
+	if o.closed {
+		sub.close()
+	} else {
+		o.subscribers = append(o.subscribers, sub)
+	}
+	o.mu.Unlock()
+
+	go func() {
+		defer close(sub.done)
+		for {
+			select {
+			case e := <-sub.events:
+				onNext(e)
+				continue
+			case <-sub.quit:
+			}
+			for {
+				select {
+				case e := <-sub.events:
+					onNext(e)
+					continue
+				default:
+				}
+				if onComplete != nil {
+					onComplete()
+				}
+				return
+			}
+		}
+	}()
+	return sub
+}
+
+// Emit delivers an event to all current subscribers, applying each
+// subscriber's backpressure strategy. Events emitted after Close are ignored.
+func (o *Observable[T]) Emit(e T) {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	subscribers := append([]*Subscription[T](nil), o.subscribers...)
+	o.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.deliver(e)
+	}
+}
+
+// Close completes the observable and every subscription.
+func (o *Observable[T]) Close() {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	o.closed = true
+	subscribers := o.subscribers
+	o.subscribers = nil
+	o.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.close()
+	}
+}
+
+// Unsubscribe stops delivery; events already buffered are still handled.
+func (s *Subscription[T]) Unsubscribe() {
+	o := s.source
+	o.mu.Lock()
+	for i, sub := range o.subscribers {
+		if sub == s {
+			o.subscribers = append(o.subscribers[:i], o.subscribers[i+1:]...)
+			break
+		}
+	}
+	o.mu.Unlock()
+	s.close()
+}
+
+// Wait blocks until the subscriber has handled its last event.
+func (s *Subscription[T]) Wait() {
+	<-s.done
+}
+
+// Dropped reports how many events the backpressure strategy discarded.
+func (s *Subscription[T]) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription[T]) deliver(e T) {
+	if s.strategy == Block {
+		select {
+		case s.events <- e:
+		case <-s.quit:
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- e:
+		return
+	default:
+	}
+	if s.strategy == Latest {
+		select {
+		case <-s.events:
+		default:
+		}
+		select {
+		case s.events <- e:
+		default:
+		}
+	}
+	s.dropped++
+}
+
+func (s *Subscription[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.quit)
+	}
+}
+
+// Filter returns an observable that re-emits only events matching predicate.
+func (o *Observable[T]) Filter(predicate func(T) bool) *Observable[T] {
+	out := NewObservable[T]()
+	o.Subscribe(1, Block, func(e T) {
+		if predicate(e) {
+			out.Emit(e)
+		}
+	}, out.Close)
+	return out
+}
+
+func (o *Observable[T]) Map(transform func(T) T) *Observable[T] {
+	out := NewObservable[T]()
+	o.Subscribe(1, Block, func(e T) { out.Emit(transform(e)) }, out.Close)
+	return out
+}
+
+// Debounce emits an event only after no newer event arrived for the given
+// quiet period; the pending event is flushed when the source closes. Flushes
+// hold the lock while emitting, so a timer firing during Close can neither
+// emit after completion nor lose the pending event.
+func (o *Observable[T]) Debounce(quiet time.Duration) *Observable[T] {
+	out := NewObservable[T]()
+	var mu sync.Mutex
+	var timer *time.Timer
+	var pending T
+	var hasPending bool
+
+	flush := func() {
+		if hasPending {
+			hasPending = false
+			out.Emit(pending)
+		}
+	}
+
+	o.Subscribe(1, Block, func(e T) {
+		mu.Lock()
+		defer mu.Unlock()
+		pending, hasPending = e, true
+		if timer != nil {
+			timer.Stop()
+		}
+		var t *time.Timer
+		t = time.AfterFunc(quiet, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			// A timer replaced or stopped after it fired does nothing.
+			if timer == t {
+				timer = nil
+				flush()
+			}
+		})
+		timer = t
+	}, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		flush()
+		out.Close()
+	})
+	return out
+}
+
+// Buffer groups events into batches of up to size events. A partial batch
+// is emitted when maxWait elapses (if positive) or the source closes.
+func Buffer[T any](o *Observable[T], size int, maxWait time.Duration) *Observable[[]T] {
+	out := NewObservable[[]T]()
+	var mu sync.Mutex
+	var batch []T
+	var timer *time.Timer
+
+	// flush runs with mu held, like the flushes of Debounce.
+	flush := func() {
+		b := batch
+		batch = nil
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		if len(b) > 0 {
+			out.Emit(b)
+		}
+	}
+
+	o.Subscribe(1, Block, func(e T) {
+		mu.Lock()
+		defer mu.Unlock()
+		batch = append(batch, e)
+		if len(batch) >= size {
+			flush()
+			return
+		}
+		if maxWait > 0 && timer == nil {
+			var t *time.Timer
+			t = time.AfterFunc(maxWait, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if timer == t {
+					flush()
+				}
+			})
+			timer = t
+		}
+	}, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		flush()
+		out.Close()
+	})
+	return out
+}
+
+// Observe emits every element of the stream and closes the observable.
+func (s Stream[T]) Observe(o *Observable[T]) {
+	for _, e := range s.elements {
+		o.Emit(e)
+	}
+	o.Close()
+}
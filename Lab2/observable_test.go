@@ -0,0 +1,87 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect subscribes to o and returns a function waiting for its completion
+// and returning the events received before it.
+func collect[T any](o *Observable[T]) func() ([]T, bool) {
+	var mu sync.Mutex
+	var events []T
+	completed, lateEvents := false, false
+	sub := o.Subscribe(16, Block, func(e T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if completed {
+			lateEvents = true
+		}
+		events = append(events, e)
+	}, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		completed = true
+	})
+	return func() ([]T, bool) {
+		sub.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return events, completed && !lateEvents
+	}
+}
+
+func TestDebounceEmitsLatest(t *testing.T) {
+	source := NewObservable[int]()
+	wait := collect(source.Debounce(20 * time.Millisecond))
+	for i := 1; i <= 3; i++ {
+		source.Emit(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	source.Emit(4)
+	source.Emit(5)
+	source.Close()
+	if events, ok := wait(); !slices.Equal(events, []int{3, 5}) || !ok {
+		t.Errorf("got %v (completed cleanly: %v), want [3 5]", events, ok)
+	}
+}
+
+// The timer may fire while the source closes; the pending event must be
+// emitted exactly once, before completion.
+func TestDebounceCloseRace(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		source := NewObservable[int]()
+		wait := collect(source.Debounce(time.Duration(i%5) * time.Microsecond))
+		source.Emit(i)
+		time.Sleep(time.Duration(i%7) * time.Microsecond)
+		source.Close()
+		if events, ok := wait(); !slices.Equal(events, []int{i}) || !ok {
+			t.Fatalf("run %d: got %v (completed cleanly: %v), want [%d]", i, events, ok, i)
+		}
+	}
+}
+
+// The timer may flush part of a batch at any time, but every element is
+// emitted once, in order and in batches of at most the size, before completion.
+func TestBufferCloseRace(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		source := NewObservable[int]()
+		wait := collect(Buffer(source, 3, time.Duration(i%5)*50*time.Microsecond))
+		for j := 0; j < 4; j++ {
+			source.Emit(j)
+		}
+		time.Sleep(time.Duration(i%7) * 30 * time.Microsecond)
+		source.Close()
+		batches, ok := wait()
+		if !ok || !slices.Equal(slices.Concat(batches...), []int{0, 1, 2, 3}) {
+			t.Fatalf("run %d: got %v (completed cleanly: %v)", i, batches, ok)
+		}
+		for _, batch := range batches {
+			if len(batch) == 0 || len(batch) > 3 {
+				t.Fatalf("run %d: got batches %v", i, batches)
+			}
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a parsed statement of the hospital query language:
+//
+//	<source> [where <field> <op> <value> {and|or ...}] [order by <field> [asc|desc] {, ...}] [limit <n>]
+//
+// Sources are doctors, patients and hospitals. Fields are the render column
+// names or Go field names of the source type, with dots for nested records
+// (patients where doctor.name = 'Dr. Smith'). Operators are = != > >= < <=
+// and contains; "and" binds tighter than "or".
+type Query struct {
+	Source  string
+	Where   [][]Condition
+	OrderBy []Ordering
+	Limit   int
+}
+
+type Condition struct {
+	Field    fieldRef
+	Operator string
+	Value    any
+}
+
+type Ordering struct {
+	Field      fieldRef
+	Descending bool
+}
+
+type fieldRef struct {
+	Name  string
+	index []int
+	kind  reflect.Kind
+}
+
+type ParseError struct {
+	Query string
+	Pos   int
+	Msg   string
+}
+
+var querySources = map[string]reflect.Type{
+	"doctors":   reflect.TypeFor[Doctor](),
+	"patients":  reflect.TypeFor[Patient](),
+	"hospitals": reflect.TypeFor[Hospital](),
+}
+
+var queryOperators = []string{"=", "==", "!=", ">", ">=", "<", "<=", "contains"}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.column(), e.Msg)
+}
+
+// Pointer returns the query with a caret under the offending position.
+func (e *ParseError) Pointer() string {
+	return e.Query + "\n" + strings.Repeat(" ", e.column()-1) + "^"
+}
+
+func (e *ParseError) column() int {
+	return utf8.RuneCountInString(e.Query[:min(e.Pos, len(e.Query))]) + 1
+}
+
+// Run executes the query against a hospital and renders the result.
+func (q *Query) Run(h Hospital, w io.Writer, r Renderer) error {
+	switch q.Source {
+	case "doctors":
+		return applyQuery(q, CreateStream(h.Doctors)).Render(w, r)
+	case "patients":
+		return applyQuery(q, CreateStream(h.Patients)).Render(w, r)
+	case "hospitals":
+		return applyQuery(q, CreateStream([]Hospital{h})).Render(w, r)
+	default:
+		return fmt.Errorf("unknown source %q", q.Source)
+	}
+}
+
+func applyQuery[T Displayable](q *Query, s Stream[T]) Stream[T] {
+	if len(q.Where) > 0 {
+		s = s.Filter(func(e T) bool { return q.matches(reflect.ValueOf(e)) })
+	}
+	order := Comparator[T](func(a, b T) int { return q.compare(reflect.ValueOf(a), reflect.ValueOf(b)) })
+	switch {
+	case len(q.OrderBy) > 0 && q.Limit >= 0:
+		s = s.TopK(q.Limit, order)
+	case len(q.OrderBy) > 0:
+		s = s.SortBy(order)
+	case q.Limit >= 0:
+		s = s.Limit(q.Limit)
+	}
+	return s
+}
+
+func (q *Query) matches(v reflect.Value) bool {
+	for _, group := range q.Where {
+		matched := true
+		for _, c := range group {
+			if !c.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Query) compare(a, b reflect.Value) int {
+	for _, o := range q.OrderBy {
+		c := compareValues(a.FieldByIndex(o.Field.index), b.FieldByIndex(o.Field.index))
+		if o.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (c Condition) matches(v reflect.Value) bool {
+	field := v.FieldByIndex(c.Field.index)
+	var cmp int
+	switch value := c.Value.(type) {
+	case float64:
+		cmp = compareFloats(numericValue(field), value)
+	case bool:
+		cmp = compareFloats(boolValue(field.Bool()), boolValue(value))
+	case string:
+		text := strings.ToLower(field.String())
+		value = strings.ToLower(value)
+		if c.Operator == "contains" {
+			return strings.Contains(text, value)
+		}
+		// The same collation as order by, so where and order by agree.
+		cmp = UkrainianCollator.Compare(text, value)
+	}
+
+	switch c.Operator {
+	case "=", "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.String:
+		return UkrainianCollator.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return compareFloats(boolValue(a.Bool()), boolValue(b.Bool()))
+	default:
+		return compareFloats(numericValue(a), numericValue(b))
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func numericValue(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	case v.CanFloat():
+		return v.Float()
+	}
+	return 0
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type queryParser struct {
+	input  string
+	tokens []token
+	next   int
+}
+
+func ParseQuery(input string) (*Query, error) {
+	tokens, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{input: input, tokens: tokens}
+	return p.parse()
+}
+
+func lexQuery(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(input) && input[i] == '=' {
+				i++
+			}
+			if input[start:i] == "!" {
+				return nil, &ParseError{input, start, `"!" must be followed by "="`}
+			}
+			tokens = append(tokens, token{tokenOperator, input[start:i], start})
+		case r == '\'' || r == '"':
+			end := strings.IndexRune(input[i+1:], r)
+			if end < 0 {
+				return nil, &ParseError{input, i, "unterminated string literal"}
+			}
+			tokens = append(tokens, token{tokenString, input[i+1 : i+1+end], i})
+			i += end + 2
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			start := i
+			for i < len(input) && strings.IndexByte("0123456789.-", input[i]) >= 0 {
+				i++
+			}
+			if _, err := strconv.ParseFloat(input[start:i], 64); err != nil {
+				return nil, &ParseError{input, start, fmt.Sprintf("invalid number %q", input[start:i])}
+			}
+			tokens = append(tokens, token{tokenNumber, input[start:i], start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(input) {
+				r, size := utf8.DecodeRuneInString(input[i:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{tokenWord, input[start:i], start})
+		default:
+			return nil, &ParseError{input, i, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(input)}), nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *queryParser) keyword(words ...string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && slicesContainFold(words, t.text)
+}
+
+func (p *queryParser) errorf(t token, format string, args ...any) error {
+	return &ParseError{p.input, t.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) parse() (*Query, error) {
+	q := &Query{Limit: -1}
+
+	source := p.advance()
+	itemType, ok := querySources[strings.ToLower(source.text)]
+	if source.kind != tokenWord || !ok {
+		return nil, p.errorf(source, "expected a source (doctors, patients or hospitals)%s", suggestion(source.text, sortedKeys(querySources)))
+	}
+	q.Source = strings.ToLower(source.text)
+
+	if p.keyword("where") {
+		p.advance()
+		where, err := p.parseWhere(itemType)
+		if err != nil {
+			return nil, err
+		}
+		q.Where = where
+	}
+
+	if p.keyword("order") {
+		p.advance()
+		if !p.keyword("by") {
+			return nil, p.errorf(p.peek(), `expected "by" after "order"`)
+		}
+		p.advance()
+		orderBy, err := p.parseOrderBy(itemType)
+		if err != nil {
+			return nil, err
+		}
+		q.OrderBy = orderBy
+	}
+
+	if p.keyword("limit") {
+		p.advance()
+		t := p.advance()
+		n, err := strconv.Atoi(t.text)
+		if t.kind != tokenNumber || err != nil || n < 0 {
+			return nil, p.errorf(t, "limit expects a non-negative whole number")
+		}
+		q.Limit = n
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %q, expected where, order by or limit", t.text)
+	}
+	return q, nil
+}
+
+func (p *queryParser) parseWhere(itemType reflect.Type) ([][]Condition, error) {
+	var where [][]Condition
+	var group []Condition
+	for {
+		c, err := p.parseCondition(itemType)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, c)
+
+		switch {
+		case p.keyword("and"):
+			p.advance()
+		case p.keyword("or"):
+			p.advance()
+			where = append(where, group)
+			group = nil
+		default:
+			return append(where, group), nil
+		}
+	}
+}
+
+func (p *queryParser) parseCondition(itemType reflect.Type) (Condition, error) {
+	fieldToken := p.advance()
+	if fieldToken.kind != tokenWord {
+		return Condition{}, p.errorf(fieldToken, "expected a field name")
+	}
+	field, err := resolveField(itemType, fieldToken.text)
+	if err != nil {
+		return Condition{}, p.errorf(fieldToken, "%v", err)
+	}
+
+	opToken := p.advance()
+	op := strings.ToLower(opToken.text)
+	if (opToken.kind != tokenOperator && opToken.kind != tokenWord) || !slicesContainFold(queryOperators, op) {
+		return Condition{}, p.errorf(opToken, "expected an operator (%s)", strings.Join(queryOperators, " "))
+	}
+
+	valueToken := p.advance()
+	if valueToken.kind != tokenNumber && valueToken.kind != tokenString && valueToken.kind != tokenWord {
+		return Condition{}, p.errorf(valueToken, "expected a value after %q", opToken.text)
+	}
+
+	c := Condition{Field: field, Operator: op}
+	switch field.kind {
+	case reflect.String:
+		c.Value = valueToken.text
+	case reflect.Bool:
+		b, err := strconv.ParseBool(valueToken.text)
+		if err != nil {
+			return Condition{}, p.errorf(valueToken, "%s is true or false", field.Name)
+		}
+		c.Value = b
+	default:
+		n, err := strconv.ParseFloat(valueToken.text, 64)
+		if valueToken.kind != tokenNumber || err != nil {
+			return Condition{}, p.errorf(valueToken, "%s is a number, got %q", field.Name, valueToken.text)
+		}
+		c.Value = n
+	}
+	if op == "contains" && field.kind != reflect.String {
+		return Condition{}, p.errorf(opToken, "contains only works on text fields, %s is not text", field.Name)
+	}
+	return c, nil
+}
+
+func (p *queryParser) parseOrderBy(itemType reflect.Type) ([]Ordering, error) {
+	var orderBy []Ordering
+	for {
+		t := p.advance()
+		if t.kind != tokenWord {
+			return nil, p.errorf(t, "expected a field name to order by")
+		}
+		field, err := resolveField(itemType, t.text)
+		if err != nil {
+			return nil, p.errorf(t, "%v", err)
+		}
+		o := Ordering{Field: field}
+		if p.keyword("asc", "desc") {
+			o.Descending = strings.EqualFold(p.advance().text, "desc")
+		}
+		orderBy = append(orderBy, o)
+
+		if p.peek().kind != tokenComma {
+			return orderBy, nil
+		}
+		p.advance()
+	}
+}
+
+// resolveField maps a dotted field name to a reflect index path, matching
+// render column names and Go field names case-insensitively.
+func resolveField(t reflect.Type, name string) (fieldRef, error) {
+	ref := fieldRef{Name: name}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		var names []string
+		found := false
+		for _, c := range columnsOf(t) {
+			if c.index < 0 {
+				continue
+			}
+			field := t.Field(c.index)
+			names = append(names, c.Name)
+			if strings.EqualFold(part, c.Name) || strings.EqualFold(part, field.Name) {
+				ref.index = append(ref.index, c.index)
+				t = field.Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fieldRef{}, fmt.Errorf("unknown field %q%s", strings.Join(parts[:i+1], "."), suggestion(part, names))
+		}
+		if i < len(parts)-1 {
+			switch t.Kind() {
+			case reflect.Struct:
+			case reflect.Slice, reflect.Array, reflect.Map:
+				return fieldRef{}, fmt.Errorf("%s is a list and cannot be compared", strings.Join(parts[:i+1], "."))
+			default:
+				return fieldRef{}, fmt.Errorf("%s has no fields", strings.Join(parts[:i+1], "."))
+			}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return fieldRef{}, fmt.Errorf("%s is a record, pick one of its fields, e.g. %s.%s", name, name, columnsOf(t)[0].Name)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fieldRef{}, fmt.Errorf("%s is a list and cannot be compared", name)
+	}
+	ref.kind = t.Kind()
+	return ref, nil
+}
+
+func suggestion(input string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(input), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func slicesContainFold(words []string, s string) bool {
+	for _, w := range words {
+		if strings.EqualFold(w, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
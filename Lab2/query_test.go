@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *Query
+	}{
+		{"doctors", &Query{Source: "doctors", Limit: -1}},
+		{"Patients limit 5", &Query{Source: "patients", Limit: 5}},
+		{
+			"doctors where salary > 4500 and name contains 'сми' or id = 3 order by name desc, salary limit 2",
+			&Query{
+				Source: "doctors",
+				Where: [][]Condition{
+					{{Field: fieldRef{Name: "salary", index: []int{1}, kind: reflect.Int}, Operator: ">", Value: 4500.0},
+						{Field: fieldRef{Name: "name", index: []int{0}, kind: reflect.String}, Operator: "contains", Value: "сми"}},
+					{{Field: fieldRef{Name: "id", index: []int{2}, kind: reflect.Int}, Operator: "=", Value: 3.0}},
+				},
+				OrderBy: []Ordering{
+					{Field: fieldRef{Name: "name", index: []int{0}, kind: reflect.String}, Descending: true},
+					{Field: fieldRef{Name: "salary", index: []int{1}, kind: reflect.Int}},
+				},
+				Limit: 2,
+			},
+		},
+		{
+			`patients where doctor.name != "Dr. Smith"`,
+			&Query{
+				Source: "patients",
+				Where:  [][]Condition{{{Field: fieldRef{Name: "doctor.name", index: []int{2, 0}, kind: reflect.String}, Operator: "!=", Value: "Dr. Smith"}}},
+				Limit:  -1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.input)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+		msg    string
+	}{
+		{"", 1, "expected a source"},
+		{"doctor", 1, `did you mean "doctors"?`},
+		{"doctors where salry > 1", 15, `unknown field "salry", did you mean "salary"?`},
+		{"patients where doctor.nme = 'x'", 16, `unknown field "doctor.nme"`},
+		{"hospitals where patients.name = 'x'", 17, "patients is a list and cannot be compared"},
+		{"hospitals order by doctors", 20, "doctors is a list and cannot be compared"},
+		{"patients where doctor = 'x'", 16, "doctor is a record"},
+		{"doctors where name ~ 'x'", 20, `unexpected character '~'`},
+		{"doctors where name like 'x'", 20, "expected an operator"},
+		{"doctors where name ! 'x'", 20, `"!" must be followed by "="`},
+		{"doctors where salary contains 5", 22, "contains only works on text fields"},
+		{"doctors where name = 'Сміт", 22, "unterminated string literal"},
+		{`doctors where name = "x`, 22, "unterminated string literal"},
+		{"doctors where salary > 'x'", 24, "salary is a number"},
+		{"doctors where salary >", 23, "expected a value"},
+		{"doctors order name", 15, `expected "by" after "order"`},
+		{"doctors limit -1", 15, "limit expects a non-negative whole number"},
+		{"doctors limit 1.5", 15, "limit expects a non-negative whole number"},
+		{"doctors where salary > 1.2.3", 24, `invalid number "1.2.3"`},
+		{"doctors limit 1 where id = 1", 17, `unexpected "where"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.input)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("ParseQuery(%q): got %v, want a ParseError", tt.input, err)
+			continue
+		}
+		if perr.column() != tt.column || !strings.Contains(perr.Msg, tt.msg) {
+			t.Errorf("ParseQuery(%q): got column %d %q, want column %d containing %q", tt.input, perr.column(), perr.Msg, tt.column, tt.msg)
+		}
+	}
+}
+
+func TestParseErrorPointer(t *testing.T) {
+	_, err := ParseQuery("doctors where name = 'Сміт' and вік > 1")
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %v, want a ParseError", err)
+	}
+	// Columns count characters, not bytes.
+	want := "doctors where name = 'Сміт' and вік > 1\n" + strings.Repeat(" ", 32) + "^"
+	if got := perr.Pointer(); got != want {
+		t.Errorf("Pointer() = %q, want %q", got, want)
+	}
+}
+
+func TestWhereAgreesWithOrderBy(t *testing.T) {
+	names := []string{"Ґонта", "Гнат", "Єва", "Жанна", "Іра", "Їжак", "Дмитро", "Яна"}
+	var doctors []Doctor
+	for _, name := range names {
+		doctors = append(doctors, Doctor{Name: name})
+	}
+	h := Hospital{Doctors: doctors}
+
+	ordered := runQuery(t, h, "doctors order by name")
+	below := runQuery(t, h, "doctors where name < 'Ж' order by name")
+	if len(below) == 0 || !reflect.DeepEqual(below, ordered[:len(below)]) {
+		t.Errorf("where name < 'Ж' = %v, want a prefix of %v", below, ordered)
+	}
+	for _, name := range ordered[len(below):] {
+		if UkrainianCollator.Compare(strings.ToLower(name), "ж") < 0 {
+			t.Errorf("%s sorts before Ж but was not selected", name)
+		}
+	}
+}
+
+// runQuery returns the names the query selects, in order.
+func runQuery(t *testing.T, h Hospital, input string) []string {
+	t.Helper()
+	q, err := ParseQuery(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range applyQuery(q, CreateStream(h.Doctors)).elements {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+func TestREPLReportsErrors(t *testing.T) {
+	// A bad query prints its error and the console goes on.
+	var out strings.Builder
+	runREPL(*testHospital(), strings.NewReader("doctors limit x\nformat xml\ndoctors limit 1\n"), &out)
+	got := out.String()
+	for _, want := range []string{"^", "Error: ", "limit expects", "Smith"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+}
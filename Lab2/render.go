@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Renderer writes a table of stream elements in a particular format.
+type Renderer interface {
+	Render(w io.Writer, table Table) error
+}
+
+// Table is the format-independent view of a stream handed to a Renderer.
+type Table struct {
+	Columns []Column
+	Rows    []reflect.Value
+}
+
+// Column describes one rendered field. Columns come from the `render`
+// struct tag: `render:"name,order"`, where order is optional and "-" skips
+// the field. Fields without a tag use their Go name and keep declaration order.
+type Column struct {
+	Name  string
+	Order int
+	index int
+}
+
+type TableRenderer struct{}
+
+type JSONLinesRenderer struct{}
+
+type CSVRenderer struct{}
+
+type YAMLRenderer struct{}
+
+// RendererFor returns the renderer registered under a format name.
+func RendererFor(format string) (Renderer, error) {
+	switch strings.ToLower(format) {
+	case "table", "text":
+		return TableRenderer{}, nil
+	case "json", "jsonl":
+		return JSONLinesRenderer{}, nil
+	case "csv":
+		return CSVRenderer{}, nil
+	case "yaml", "yml":
+		return YAMLRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func (s Stream[T]) Render(w io.Writer, r Renderer) error {
+	defer s.finish("Render", time.Now(), len(s.elements), "")
+	table := Table{Columns: columnsOf(reflect.TypeFor[T]())}
+	for _, e := range s.elements {
+		table.Rows = append(table.Rows, reflect.ValueOf(e))
+	}
+	return r.Render(w, table)
+}
+
+func columnsOf(t reflect.Type) []Column {
+	if t.Kind() != reflect.Struct {
+		return []Column{{Name: "value", index: -1}}
+	}
+
+	var columns []Column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column := Column{Name: field.Name, Order: i, index: i}
+		if tag, ok := field.Tag.Lookup("render"); ok {
+			name, order, hasOrder := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				column.Name = name
+			}
+			if hasOrder {
+				if n, err := strconv.Atoi(order); err == nil {
+					column.Order = n
+				}
+			}
+		}
+		columns = append(columns, column)
+	}
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].Order < columns[j].Order })
+	return columns
+}
+
+func (c Column) value(row reflect.Value) reflect.Value {
+	if c.index < 0 {
+		return row
+	}
+	return row.Field(c.index)
+}
+
+// cellText flattens a value into a single cell for tabular formats: nested
+// structs are shown by their first column and slices as a "; " separated list.
+func cellText(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Struct:
+		columns := columnsOf(v.Type())
+		if len(columns) == 0 || columns[0].index < 0 {
+			return displayText(v)
+		}
+		return cellText(columns[0].value(v))
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = cellText(v.Index(i))
+		}
+		return strings.Join(items, "; ")
+	default:
+		return displayText(v)
+	}
+}
+
+func displayText(v reflect.Value) string {
+	if d, ok := v.Interface().(Displayable); ok {
+		return d.display()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func (TableRenderer) Render(w io.Writer, table Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(table.Columns))
+	rule := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		header[i] = strings.ToUpper(c.Name)
+		rule[i] = strings.Repeat("-", len(c.Name))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	fmt.Fprintln(tw, strings.Join(rule, "\t"))
+	for _, row := range table.Rows {
+		cells := make([]string, len(table.Columns))
+		for i, c := range table.Columns {
+			cells[i] = cellText(c.value(row))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (CSVRenderer) Render(w io.Writer, table Table) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		record := make([]string, len(table.Columns))
+		for i, c := range table.Columns {
+			record[i] = cellText(c.value(row))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (JSONLinesRenderer) Render(w io.Writer, table Table) error {
+	for _, row := range table.Rows {
+		var b strings.Builder
+		if err := writeJSON(&b, row); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON encodes structs as objects keyed and ordered by their render
+// columns, so JSON output matches the other formats.
+func writeJSON(b *strings.Builder, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		b.WriteByte('{')
+		for i, c := range columnsOf(v.Type()) {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(c.Name)
+			b.Write(key)
+			b.WriteByte(':')
+			if c.index < 0 {
+				text, _ := json.Marshal(displayText(v))
+				b.Write(text)
+				continue
+			}
+			if err := writeJSON(b, c.value(v)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	return nil
+}
+
+func (YAMLRenderer) Render(w io.Writer, table Table) error {
+	var b strings.Builder
+	if len(table.Rows) == 0 {
+		b.WriteString("[]\n")
+	}
+	for _, row := range table.Rows {
+		writeYAMLItem(&b, row, 0)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeYAMLItem(b *strings.Builder, v reflect.Value, indent int) {
+	pad := strings.Repeat(" ", indent)
+	if v.Kind() != reflect.Struct {
+		b.WriteString(pad + "- " + yamlScalar(v) + "\n")
+		return
+	}
+	for i, c := range columnsOf(v.Type()) {
+		prefix := pad + "  "
+		if i == 0 {
+			prefix = pad + "- "
+		}
+		writeYAMLField(b, prefix, indent+2, c.Name, c.value(v))
+	}
+}
+
+func writeYAMLField(b *strings.Builder, prefix string, indent int, name string, v reflect.Value) {
+	b.WriteString(prefix + yamlString(name) + ":")
+	switch v.Kind() {
+	case reflect.Struct:
+		b.WriteString("\n")
+		pad := strings.Repeat(" ", indent+2)
+		for _, c := range columnsOf(v.Type()) {
+			writeYAMLField(b, pad, indent+2, c.Name, c.value(v))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		for i := 0; i < v.Len(); i++ {
+			writeYAMLItem(b, v.Index(i), indent+2)
+		}
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func yamlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return yamlString(v.String())
+	case reflect.Struct:
+		return yamlString(displayText(v))
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// yamlString quotes a string only when a plain scalar would be misread.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if strings.TrimSpace(s) != s || strings.ContainsAny(s, "\"'\n\t") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>%@`") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const replHelp = `Query syntax:
+  <source> [where <field> <op> <value> {and|or ...}] [order by <field> [asc|desc], ...] [limit <n>]
+Sources: doctors, patients, hospitals
+Operators: = != > >= < <= contains
+Examples:
+  doctors where salary > 4500 order by name limit 5
+  patients where doctor.name = 'Dr. Smith' order by age desc
+Commands:
+  format table|json|csv|yaml   change the output format
+  help                         show this message
+  exit                         leave the console`
+
+// runREPL reads queries line by line and prints their results until the
+// input ends or the user types exit.
+func runREPL(h Hospital, in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	var renderer Renderer = TableRenderer{}
+
+	fmt.Fprintln(out, "Hospital query console. Type 'help' for syntax, 'exit' to quit.")
+	for {
+		fmt.Fprint(out, "query> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+
+		switch command, arg, _ := strings.Cut(line, " "); strings.ToLower(command) {
+		case "":
+			continue
+		case "exit", "quit":
+			return
+		case "help":
+			fmt.Fprintln(out, replHelp)
+			continue
+		case "format":
+			r, err := RendererFor(strings.TrimSpace(arg))
+			if err != nil {
+				fmt.Fprintln(out, "Error:", err)
+				continue
+			}
+			renderer = r
+			continue
+		}
+
+		q, err := ParseQuery(line)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			fmt.Fprintln(out, parseErr.Pointer())
+		}
+		if err != nil {
+			fmt.Fprintln(out, "Error:", err)
+			continue
+		}
+		if err := q.Run(h, out, renderer); err != nil {
+			fmt.Fprintln(out, "Error:", err)
+		}
+	}
+}
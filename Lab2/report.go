@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// HospitalReport gathers payroll and workload statistics of a hospital.
+type HospitalReport struct {
+	Hospital               string           `json:"hospital"`
+	Location               string           `json:"location"`
+	Payroll                int              `json:"payroll"`
+	Salaries               SalaryStats      `json:"salaries"`
+	PatientAges            []DoctorPatients `json:"patient_ages"`
+	DoctorsWithoutPatients []string         `json:"doctors_without_patients"`
+	SalaryBands            []SalaryBand     `json:"salary_bands"`
+}
+
+type SalaryStats struct {
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+type DoctorPatients struct {
+	Doctor     string  `json:"doctor"`
+	Patients   int     `json:"patients"`
+	AverageAge float64 `json:"average_age"`
+}
+
+type SalaryBand struct {
+	Low     int      `json:"low"`
+	High    int      `json:"high"`
+	Doctors []string `json:"doctors"`
+}
+
+func (b SalaryBand) display() string {
+	return fmt.Sprintf("Salary band: %d - %d, Doctors: %s", b.Low, b.High, strings.Join(b.Doctors, ", "))
+}
+
+func (d DoctorPatients) display() string {
+	return fmt.Sprintf("Doctor: %s, Patients: %d, Average age: %.1f", d.Doctor, d.Patients, d.AverageAge)
+}
+
+// BuildReport computes the report with Stream operators. Salary bands are
+// bandWidth wide and start at multiples of it.
+func BuildReport(h Hospital, bandWidth int) HospitalReport {
+	doctors := CreateStream(h.Doctors)
+	salaries := doctors.Summarize(func(d Doctor) float64 { return float64(d.Salary) })
+
+	report := HospitalReport{
+		Hospital: h.Name,
+		Location: h.Location,
+		Payroll:  doctors.Reduce(0, func(acc int, d Doctor) int { return acc + d.Salary }),
+	}
+	// Without doctors the salary stats stay zero; JSON has no NaN.
+	if salaries.Count > 0 {
+		report.Salaries = SalaryStats{Mean: salaries.Mean, Median: salaries.Median, Min: salaries.Min, Max: salaries.Max}
+	}
+
+	report.PatientAges = MapTo(doctors.Filter(func(d Doctor) bool { return h.PatientsOf(d.Name).Count() > 0 }),
+		func(d Doctor) DoctorPatients {
+			ages := h.PatientsOf(d.Name).Summarize(func(p Patient) float64 { return float64(p.Age) })
+			return DoctorPatients{Doctor: d.Name, Patients: ages.Count, AverageAge: ages.Mean}
+		}).
+		SortBy(ByCollated(func(d DoctorPatients) string { return d.Doctor }, UkrainianCollator)).
+		ToSlice()
+
+	report.DoctorsWithoutPatients = doctorNames(doctors.Filter(func(d Doctor) bool { return h.PatientsOf(d.Name).Count() == 0 }))
+
+	if bandWidth > 0 {
+		var bands []SalaryBand
+		for band, members := range GroupBy(doctors, func(d Doctor) int { return d.Salary / bandWidth }) {
+			bands = append(bands, SalaryBand{
+				Low:     band * bandWidth,
+				High:    (band+1)*bandWidth - 1,
+				Doctors: doctorNames(members),
+			})
+		}
+		report.SalaryBands = CreateStream(bands).SortBy(By(func(b SalaryBand) int { return b.Low })).ToSlice()
+	}
+	return report
+}
+
+func doctorNames(s Stream[Doctor]) []string {
+	doctors := s.SortBy(ByCollated(func(d Doctor) string { return d.Name }, UkrainianCollator)).ToSlice()
+	names := make([]string, 0, len(doctors))
+	for _, d := range doctors {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+func (r HospitalReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r HospitalReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Report for %s, %s\n\n", r.Hospital, r.Location)
+	fmt.Fprintf(tw, "Payroll total:\t%d\n", r.Payroll)
+	fmt.Fprintf(tw, "Salary mean / median:\t%.2f / %.2f\n", r.Salaries.Mean, r.Salaries.Median)
+	fmt.Fprintf(tw, "Salary range:\t%.0f - %.0f\n", r.Salaries.Min, r.Salaries.Max)
+
+	fmt.Fprintln(tw, "\nAverage patient age per doctor:")
+	fmt.Fprintln(tw, "  DOCTOR\tPATIENTS\tAVERAGE AGE")
+	for _, d := range r.PatientAges {
+		fmt.Fprintf(tw, "  %s\t%d\t%.1f\n", d.Doctor, d.Patients, d.AverageAge)
+	}
+
+	fmt.Fprintln(tw, "\nDoctors without patients:")
+	if len(r.DoctorsWithoutPatients) == 0 {
+		fmt.Fprintln(tw, "  none")
+	}
+	for _, name := range r.DoctorsWithoutPatients {
+		fmt.Fprintf(tw, "  %s\n", name)
+	}
+
+	fmt.Fprintln(tw, "\nSalary bands:")
+	for _, b := range r.SalaryBands {
+		fmt.Fprintf(tw, "  %d - %d\t%d\t%s\n", b.Low, b.High, len(b.Doctors), strings.Join(b.Doctors, ", "))
+	}
+	return tw.Flush()
+}
+
+const reportUsage = "report [-format text|json] [-band width]"
+
+// runReport implements the report command: report [-format text|json] [-band width].
+func runReport(h Hospital, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	band := fs.Int("band", 1000, "width of a salary band")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q, usage: %s", fs.Arg(0), reportUsage)
+	}
+
+	report := BuildReport(h, *band)
+	switch *format {
+	case "text":
+		return report.WriteText(out)
+	case "json":
+		return report.WriteJSON(out)
+	default:
+		return fmt.Errorf("unknown report format %q, expected text or json", *format)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"iter"
+	"sync"
+)
+
+var (
+	ErrCacheFull = errors.New("source has more elements than the cache limit")
+	ErrTeeLag    = errors.New("tee branch ran too far ahead of the slowest branch")
+)
+
+// All exposes the stream as a sequence, e.g. to feed Cache or Tee.
+func (s Stream[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, e := range s.elements {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// FromChannel turns a channel into a one-shot source.
+func FromChannel[T Displayable](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := range ch {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Collect reads a sequence into a stream.
+func Collect[T Displayable](source iter.Seq[T]) Stream[T] {
+	var elements []T
+	for e := range source {
+		elements = append(elements, e)
+	}
+	return CreateStream(elements)
+}
+
+// Cached memoizes a one-shot source. The source is read lazily and only
+// once; every replay sees the elements read so far and pulls the rest.
+type Cached[T Displayable] struct {
+	mu    sync.Mutex
+	next  func() (T, bool)
+	stop  func()
+	items []T
+	done  bool
+	limit int
+	err   error
+}
+
+// Cache wraps source in a replayable cache holding at most limit elements;
+// a limit of zero or less means unbounded. Close releases the source when
+// it is not read to the end.
+func Cache[T Displayable](source iter.Seq[T], limit int) *Cached[T] {
+	next, stop := iter.Pull(source)
+	return &Cached[T]{next: next, stop: stop, limit: limit}
+}
+
+// All replays the cache. When the source has more than limit elements the
+// sequence ends at the limit and Err reports ErrCacheFull.
+func (c *Cached[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; ; i++ {
+			e, ok := c.at(i)
+			if !ok || !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Stream replays the whole source as a stream.
+func (c *Cached[T]) Stream() (Stream[T], error) {
+	s := Collect(c.All())
+	return s, c.Err()
+}
+
+func (c *Cached[T]) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Cached[T]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finish()
+}
+
+func (c *Cached[T]) at(i int) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	if i < len(c.items) {
+		return c.items[i], true
+	}
+	if c.done {
+		return zero, false
+	}
+	e, ok := c.next()
+	if !ok {
+		c.finish()
+		return zero, false
+	}
+	// Only an element beyond the limit means the cache overflowed.
+	if c.limit > 0 && len(c.items) >= c.limit {
+		c.err = ErrCacheFull
+		c.finish()
+		return zero, false
+	}
+	c.items = append(c.items, e)
+	return e, true
+}
+
+func (c *Cached[T]) finish() {
+	if !c.done {
+		c.done = true
+		c.stop()
+	}
+}
+
+// Branch is one consumer of a source split by Tee.
+type Branch[T Displayable] struct {
+	tee    *tee[T]
+	pos    int
+	active bool
+	err    error
+}
+
+type tee[T Displayable] struct {
+	mu       sync.Mutex
+	next     func() (T, bool)
+	stop     func()
+	buffer   []T
+	base     int
+	done     bool
+	maxLag   int
+	branches []*Branch[T]
+}
+
+// Tee splits a one-shot source into n branches that each see every element
+// while the source is read only once. Elements stay buffered only until all
+// active branches have consumed them. With maxLag > 0 a branch that would
+// need more than maxLag buffered elements stops with ErrTeeLag, which bounds
+// memory when branches are consumed concurrently at different speeds.
+func Tee[T Displayable](source iter.Seq[T], n int, maxLag int) []*Branch[T] {
+	next, stop := iter.Pull(source)
+	t := &tee[T]{next: next, stop: stop, maxLag: maxLag}
+	for i := 0; i < n; i++ {
+		t.branches = append(t.branches, &Branch[T]{tee: t, active: true})
+	}
+	return t.branches
+}
+
+func (b *Branch[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			e, ok := b.tee.read(b)
+			if !ok || !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+func (b *Branch[T]) Stream() (Stream[T], error) {
+	s := Collect(b.All())
+	b.Close()
+	return s, b.Err()
+}
+
+func (b *Branch[T]) Err() error {
+	b.tee.mu.Lock()
+	defer b.tee.mu.Unlock()
+	return b.err
+}
+
+// Close detaches the branch so the others no longer buffer elements for it.
+func (b *Branch[T]) Close() {
+	t := b.tee
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b.active = false
+	t.trim()
+}
+
+func (t *tee[T]) read(b *Branch[T]) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var zero T
+	if !b.active || b.err != nil {
+		return zero, false
+	}
+
+	if b.pos >= t.base+len(t.buffer) {
+		if t.done {
+			return zero, false
+		}
+		if t.maxLag > 0 && len(t.buffer) >= t.maxLag {
+			b.err = ErrTeeLag
+			return zero, false
+		}
+		e, ok := t.next()
+		if !ok {
+			t.done = true
+			t.stop()
+			return zero, false
+		}
+		t.buffer = append(t.buffer, e)
+	}
+
+	e := t.buffer[b.pos-t.base]
+	b.pos++
+	t.trim()
+	return e, true
+}
+
+// trim drops buffered elements every active branch has already read and
+// releases the source once no branch needs it.
+func (t *tee[T]) trim() {
+	slowest := -1
+	for _, b := range t.branches {
+		if b.active && b.err == nil && (slowest < 0 || b.pos < slowest) {
+			slowest = b.pos
+		}
+	}
+	if slowest < 0 {
+		t.buffer = nil
+		t.base = 0
+		if !t.done {
+			t.done = true
+			t.stop()
+		}
+		return
+	}
+	if drop := slowest - t.base; drop > 0 {
+		var zero T
+		for i := 0; i < drop; i++ {
+			t.buffer[i] = zero
+		}
+		t.buffer = t.buffer[drop:]
+		t.base = slowest
+	}
+}
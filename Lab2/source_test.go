@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCacheLimit(t *testing.T) {
+	doctors := []Doctor{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+	tests := []struct {
+		limit   int
+		want    int
+		wantErr error
+	}{
+		{limit: 0, want: 3},
+		{limit: 4, want: 3},
+		{limit: 3, want: 3},
+		{limit: 2, want: 2, wantErr: ErrCacheFull},
+	}
+	for _, tt := range tests {
+		c := Cache(slices.Values(doctors), tt.limit)
+		got := slices.Collect(c.All())
+		if len(got) != tt.want || c.Err() != tt.wantErr {
+			t.Errorf("limit %d: got %d elements, err %v; want %d, err %v", tt.limit, len(got), c.Err(), tt.want, tt.wantErr)
+		}
+		// A second replay sees the same elements.
+		if again := slices.Collect(c.All()); len(again) != len(got) {
+			t.Errorf("limit %d: replay got %d elements, want %d", tt.limit, len(again), len(got))
+		}
+		c.Close()
+	}
+}
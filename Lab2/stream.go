@@ -2,36 +2,44 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"time"
 )
 
 type Stream[T Displayable] struct {
 	elements []T
+	trace    *Trace
+	stage    int
 }
 
 func CreateStream[T Displayable](elements []T) Stream[T] {
-	return Stream[T]{elements}
+	return Stream[T]{elements: elements, stage: -1}
 }
 
 func (s Stream[T]) Filter(predicate func(T) bool) Stream[T] {
+	start := time.Now()
 	var filtered []T
 	for _, e := range s.elements {
 		if predicate(e) {
 			filtered = append(filtered, e)
 		}
 	}
-	return Stream[T]{filtered}
+	return s.next("Filter", start, len(s.elements), filtered)
 }
 
 func (s Stream[T]) Map(transform func(T) T) Stream[T] {
+	start := time.Now()
 	var transformed []T
 	for _, e := range s.elements {
 		transformed = append(transformed, transform(e))
 	}
-	return Stream[T]{transformed}
+	return s.next("Map", start, len(s.elements), transformed)
 }
 
 func (s Stream[T]) Max(less func(T, T) bool) *T {
+	start := time.Now()
 	if len(s.elements) == 0 {
+		s.finish("Max", start, 0, "")
 		return nil
 	}
 	max := s.elements[0]
@@ -40,18 +48,22 @@ func (s Stream[T]) Max(less func(T, T) bool) *T {
 			max = e
 		}
 	}
+	s.finish("Max", start, 1, max.display())
 	return &max
 }
 
 func (s Stream[T]) Reduce(initialValue int, accumulator func(int, T) int) int {
+	start := time.Now()
 	result := initialValue
 	for _, e := range s.elements {
 		result = accumulator(result, e)
 	}
+	s.finish("Reduce", start, 1, fmt.Sprint(result))
 	return result
 }
 
 func (s Stream[T]) Distinct() Stream[T] {
+	start := time.Now()
 	seen := make(map[string]struct{})
 	var distinct []T
 	for _, e := range s.elements {
@@ -61,11 +73,67 @@ func (s Stream[T]) Distinct() Stream[T] {
 			distinct = append(distinct, e)
 		}
 	}
-	return Stream[T]{distinct}
+	return s.next("Distinct", start, len(s.elements), distinct)
+}
+
+// Sort returns a stream ordered by less; equal elements keep their order.
+func (s Stream[T]) Sort(less func(T, T) bool) Stream[T] {
+	start := time.Now()
+	sorted := append([]T(nil), s.elements...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return s.next("Sort", start, len(s.elements), sorted)
+}
+
+func (s Stream[T]) Limit(n int) Stream[T] {
+	start := time.Now()
+	limited := s.elements
+	if n >= 0 && n < len(s.elements) {
+		limited = s.elements[:n]
+	}
+	return s.next("Limit", start, len(s.elements), limited)
+}
+
+// MapTo converts every element to another Displayable type.
+func MapTo[T, U Displayable](s Stream[T], transform func(T) U) Stream[U] {
+	start := time.Now()
+	var transformed []U
+	for _, e := range s.elements {
+		transformed = append(transformed, transform(e))
+	}
+	return Stream[U]{trace: s.trace}.next("MapTo", start, len(s.elements), transformed)
+}
+
+// GroupBy splits the stream into one stream per key, keeping element order.
+func GroupBy[T Displayable, K comparable](s Stream[T], key func(T) K) map[K]Stream[T] {
+	start := time.Now()
+	groups := make(map[K][]T)
+	for _, e := range s.elements {
+		k := key(e)
+		groups[k] = append(groups[k], e)
+	}
+	s.finish("GroupBy", start, len(groups), "")
+
+	streams := make(map[K]Stream[T], len(groups))
+	for k, elements := range groups {
+		streams[k] = CreateStream(elements)
+	}
+	return streams
+}
+
+func (s Stream[T]) ToSlice() []T {
+	s.finish("ToSlice", time.Now(), len(s.elements), "")
+	return append(make([]T, 0, len(s.elements)), s.elements...)
+}
+
+func (s Stream[T]) Count() int {
+	s.finish("Count", time.Now(), 1, fmt.Sprint(len(s.elements)))
+	return len(s.elements)
 }
 
 func (s Stream[T]) Display() {
+	start := time.Now()
 	for _, e := range s.elements {
 		fmt.Println(e.display())
 	}
-}
\ No newline at end of file
+	s.finish("Display", start, len(s.elements), "")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// quantileSampleSize caps how many values Summarize keeps for percentiles.
+// Larger inputs are reservoir-sampled, so their quantiles are approximate.
+const quantileSampleSize = 10000
+
+type Summary struct {
+	Count    int
+	Sum      float64
+	Mean     float64
+	Min      float64
+	Max      float64
+	Variance float64
+	Median   float64
+	sample   []float64
+	exact    bool
+}
+
+type HistogramBucket struct {
+	Low   float64
+	High  float64
+	Count int
+}
+
+// Summarize computes all statistics in a single pass over the stream.
+// Variance is the population variance. An empty stream has no mean,
+// extremes or median: they are NaN, as its percentiles are.
+func (s Stream[T]) Summarize(keyFn func(T) float64) Summary {
+	summary := Summary{exact: true}
+	defer func(start time.Time) { s.finish("Summarize", start, 1, summary.display()) }(time.Now())
+	var m2 float64
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, e := range s.elements {
+		v := keyFn(e)
+		summary.Count++
+		summary.Sum += v
+		if summary.Count == 1 || v < summary.Min {
+			summary.Min = v
+		}
+		if summary.Count == 1 || v > summary.Max {
+			summary.Max = v
+		}
+
+		delta := v - summary.Mean
+		summary.Mean += delta / float64(summary.Count)
+		m2 += delta * (v - summary.Mean)
+
+		if len(summary.sample) < quantileSampleSize {
+			summary.sample = append(summary.sample, v)
+		} else {
+			summary.exact = false
+			if j := rng.IntN(summary.Count); j < quantileSampleSize {
+				summary.sample[j] = v
+			}
+		}
+	}
+
+	if summary.Count == 0 {
+		nan := math.NaN()
+		summary.Mean, summary.Min, summary.Max, summary.Variance, summary.Median = nan, nan, nan, nan, nan
+		return summary
+	}
+	summary.Variance = m2 / float64(summary.Count)
+	sort.Float64s(summary.sample)
+	summary.Median = summary.Percentile(50)
+	return summary
+}
+
+// Percentile returns the p-th percentile (0-100) using linear interpolation.
+func (s Summary) Percentile(p float64) float64 {
+	if len(s.sample) == 0 {
+		return math.NaN()
+	}
+	p = math.Max(0, math.Min(100, p))
+	rank := p / 100 * float64(len(s.sample)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	return s.sample[lower] + (s.sample[upper]-s.sample[lower])*frac
+}
+
+func (s Summary) StdDev() float64 {
+	return math.Sqrt(s.Variance)
+}
+
+// Exact reports whether percentiles were computed from every value
+// rather than from a sample.
+func (s Summary) Exact() bool {
+	return s.exact
+}
+
+func (s Summary) display() string {
+	return fmt.Sprintf("Count: %d, Sum: %.2f, Mean: %.2f, Min: %.2f, Max: %.2f, Variance: %.2f, Median: %.2f",
+		s.Count, s.Sum, s.Mean, s.Min, s.Max, s.Variance, s.Median)
+}
+
+// Histogram splits the range between the smallest and largest key into
+// equal-width buckets and counts the elements falling into each of them.
+func (s Stream[T]) Histogram(keyFn func(T) float64, buckets int) []HistogramBucket {
+	defer s.finish("Histogram", time.Now(), buckets, "")
+	if buckets <= 0 || len(s.elements) == 0 {
+		return nil
+	}
+
+	values := make([]float64, len(s.elements))
+	low, high := math.Inf(1), math.Inf(-1)
+	for i, e := range s.elements {
+		values[i] = keyFn(e)
+		low = math.Min(low, values[i])
+		high = math.Max(high, values[i])
+	}
+
+	width := (high - low) / float64(buckets)
+	result := make([]HistogramBucket, buckets)
+	for i := range result {
+		result[i].Low = low + width*float64(i)
+		result[i].High = low + width*float64(i+1)
+	}
+	result[buckets-1].High = high
+
+	for _, v := range values {
+		i := buckets - 1
+		if width > 0 {
+			i = min(int((v-low)/width), buckets-1)
+		}
+		result[i].Count++
+	}
+	return result
+}
+
+func (b HistogramBucket) display() string {
+	return fmt.Sprintf("[%.2f, %.2f]: %d", b.Low, b.High, b.Count)
+}
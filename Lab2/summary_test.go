@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// reading is a number that can go through a Stream.
+type reading float64
+
+func (r reading) display() string {
+	return fmt.Sprint(float64(r))
+}
+
+func readings(values ...float64) []reading {
+	result := make([]reading, len(values))
+	for i, v := range values {
+		result[i] = reading(v)
+	}
+	return result
+}
+
+func identity(r reading) float64 {
+	return float64(r)
+}
+
+func TestSummarize(t *testing.T) {
+	s := CreateStream(readings(4, 1, 5, 2, 3)).Summarize(identity)
+	want := Summary{Count: 5, Sum: 15, Mean: 3, Min: 1, Max: 5, Variance: 2, Median: 3}
+	if s.Count != want.Count || s.Sum != want.Sum || s.Mean != want.Mean || s.Min != want.Min ||
+		s.Max != want.Max || s.Variance != want.Variance || s.Median != want.Median || !s.Exact() {
+		t.Errorf("got %s, want %s", s.display(), want.display())
+	}
+
+	empty := CreateStream(readings()).Summarize(identity)
+	if empty.Count != 0 || empty.Sum != 0 {
+		t.Errorf("empty: got count %d, sum %v", empty.Count, empty.Sum)
+	}
+	for name, v := range map[string]float64{"mean": empty.Mean, "min": empty.Min, "max": empty.Max,
+		"variance": empty.Variance, "median": empty.Median, "percentile": empty.Percentile(90)} {
+		if !math.IsNaN(v) {
+			t.Errorf("empty: %s is %v, want NaN", name, v)
+		}
+	}
+
+	large := make([]reading, quantileSampleSize+1)
+	if CreateStream(large).Summarize(identity).Exact() {
+		t.Errorf("a stream over %d values should be sampled", quantileSampleSize)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	s := CreateStream(readings(10, 40, 20, 30)).Summarize(identity)
+	tests := []struct {
+		p, want float64
+	}{
+		{0, 10},
+		{25, 17.5},
+		{50, 25},
+		{100, 40},
+		{-5, 10},
+		{150, 40},
+	}
+	for _, tt := range tests {
+		if got := s.Percentile(tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []float64
+		buckets int
+		want    []HistogramBucket
+	}{
+		{"even split", []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 10}, 2, []HistogramBucket{{0, 5, 5}, {5, 10, 5}}},
+		{"maximum in last bucket", []float64{0, 3, 9}, 3, []HistogramBucket{{0, 3, 1}, {3, 6, 1}, {6, 9, 1}}},
+		{"equal values", []float64{7, 7, 7}, 2, []HistogramBucket{{7, 7, 0}, {7, 7, 3}}},
+		{"empty", nil, 2, nil},
+		{"no buckets", []float64{1, 2}, 0, nil},
+	}
+	for _, tt := range tests {
+		got := CreateStream(readings(tt.values...)).Histogram(identity, tt.buckets)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
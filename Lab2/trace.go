@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Trace records what every stage of a traced pipeline did. Pass one to
+// Stream.Traced to opt in; untraced streams pay no tracing cost.
+type Trace struct {
+	Stages     []StageTrace
+	SampleSize int
+}
+
+type StageTrace struct {
+	Name     string
+	In       int
+	Out      int
+	Duration time.Duration
+	Samples  []string
+}
+
+func NewTrace() *Trace {
+	return &Trace{SampleSize: 3}
+}
+
+// Traced attaches t to the stream; every following stage, including the
+// terminal operation, is recorded in it.
+func (s Stream[T]) Traced(t *Trace) Stream[T] {
+	traced := Stream[T]{elements: s.elements, trace: t}
+	return traced.next("Source", time.Now(), len(s.elements), s.elements)
+}
+
+// Named renames the stage that produced this stream in the trace.
+func (s Stream[T]) Named(name string) Stream[T] {
+	if s.trace != nil && s.stage >= 0 {
+		s.trace.Stages[s.stage].Name = name
+	}
+	return s
+}
+
+// Peek calls action for every element and passes the stream on unchanged.
+func (s Stream[T]) Peek(action func(T)) Stream[T] {
+	start := time.Now()
+	for _, e := range s.elements {
+		action(e)
+	}
+	return s.next("Peek", start, len(s.elements), s.elements)
+}
+
+func (s Stream[T]) next(name string, start time.Time, in int, elements []T) Stream[T] {
+	next := Stream[T]{elements: elements, trace: s.trace, stage: -1}
+	if s.trace == nil {
+		return next
+	}
+	var samples []string
+	for _, e := range elements[:min(len(elements), s.trace.SampleSize)] {
+		samples = append(samples, e.display())
+	}
+	next.stage = s.trace.record(StageTrace{Name: name, In: in, Out: len(elements), Duration: time.Since(start), Samples: samples})
+	return next
+}
+
+// finish records a terminal operation together with a description of its result.
+func (s Stream[T]) finish(name string, start time.Time, out int, result string) {
+	if s.trace == nil {
+		return
+	}
+	var samples []string
+	if result != "" {
+		samples = []string{result}
+	}
+	s.trace.record(StageTrace{Name: name, In: len(s.elements), Out: out, Duration: time.Since(start), Samples: samples})
+}
+
+func (t *Trace) record(stage StageTrace) int {
+	t.Stages = append(t.Stages, stage)
+	return len(t.Stages) - 1
+}
+
+// Print writes the pipeline summary as an aligned table.
+func (t *Trace) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tSTAGE\tIN\tOUT\tTIME\tSAMPLES")
+	for i, stage := range t.Stages {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%v\t%s\n", i+1, stage.Name, stage.In, stage.Out, stage.Duration, strings.Join(stage.Samples, " | "))
+	}
+	return tw.Flush()
+}
+
+func (t *Trace) String() string {
+	var b strings.Builder
+	t.Print(&b)
+	return b.String()
+}
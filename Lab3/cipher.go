@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// cipherNames are the transforms the encrypt and decrypt commands accept.
+var cipherNames = map[string]bool{"caesar": true, "vigenere": true, "atbash": true, "railfence": true}
+
+// letterIndex finds a letter in the Latin or Ukrainian alphabet, ignoring
+// its case.
+func letterIndex(r rune) (alphabet []rune, index int, ok bool) {
+	lower := unicode.ToLower(r)
+	for _, alphabet := range [][]rune{latinAlphabet, ukrainianAlphabet} {
+		for i, letter := range alphabet {
+			if letter == lower {
+				return alphabet, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// withCaseOf gives letter the case of r.
+func withCaseOf(letter, r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToUpper(letter)
+	}
+	return letter
+}
+
+// vigenereTransform shifts every letter by the position of the next key
+// letter in its own alphabet. The key starts over in every word, and
+// characters outside both alphabets do not use up key letters.
+type vigenereTransform struct {
+	shifts  []int
+	decrypt bool
+}
+
+func newVigenereTransform(p *paramReader) (Transform, error) {
+	key := p.text("key", "")
+	var shifts []int
+	for _, r := range key {
+		_, i, ok := letterIndex(r)
+		if !ok {
+			return nil, fmt.Errorf("ключ кроку %q може містити лише латинські або українські літери, отримано %q", p.step, key)
+		}
+		shifts = append(shifts, i)
+	}
+	if len(shifts) == 0 {
+		return nil, fmt.Errorf("крок %q потребує параметра key", p.step)
+	}
+	return vigenereTransform{shifts: shifts}, nil
+}
+
+func (v vigenereTransform) Name() string { return "vigenere" }
+
+func (v vigenereTransform) Apply(word string) string {
+	runes := []rune(word)
+	k := 0
+	for i, r := range runes {
+		if _, _, ok := letterIndex(r); !ok {
+			continue
+		}
+		shift := v.shifts[k%len(v.shifts)]
+		if v.decrypt {
+			shift = -shift
+		}
+		runes[i] = shiftLetter(r, shift)
+		k++
+	}
+	return string(runes)
+}
+
+func (v vigenereTransform) Inverse() (Transform, error) {
+	return vigenereTransform{shifts: v.shifts, decrypt: !v.decrypt}, nil
+}
+
+// atbashTransform mirrors every letter within its alphabet: a↔z, а↔я.
+type atbashTransform struct{}
+
+func (atbashTransform) Name() string { return "atbash" }
+
+func (atbashTransform) Apply(word string) string {
+	return strings.Map(func(r rune) rune {
+		alphabet, i, ok := letterIndex(r)
+		if !ok {
+			return r
+		}
+		return withCaseOf(alphabet[len(alphabet)-1-i], r)
+	}, word)
+}
+
+func (a atbashTransform) Inverse() (Transform, error) {
+	return a, nil
+}
+
+// railFenceTransform writes the characters of a word in a zigzag over
+// rails rows and reads them row by row.
+type railFenceTransform struct {
+	rails   int
+	decrypt bool
+}
+
+func newRailFenceTransform(p *paramReader) (Transform, error) {
+	rails, err := p.int("rails", 3)
+	if err != nil {
+		return nil, err
+	}
+	if rails < 2 {
+		return nil, fmt.Errorf("параметр rails кроку %q має бути не меншим за 2", p.step)
+	}
+	return railFenceTransform{rails: rails}, nil
+}
+
+func (r railFenceTransform) Name() string { return "railfence" }
+
+func (r railFenceTransform) Apply(word string) string {
+	letters := graphemes(word)
+	order := r.order(len(letters))
+	out := make([]string, len(letters))
+	for i, j := range order {
+		if r.decrypt {
+			out[j] = letters[i]
+		} else {
+			out[i] = letters[j]
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// order lists the positions of an n-character word in the order the rails
+// are read.
+func (r railFenceTransform) order(n int) []int {
+	rows := make([][]int, r.rails)
+	row, step := 0, 1
+	for i := 0; i < n; i++ {
+		rows[row] = append(rows[row], i)
+		if row == 0 {
+			step = 1
+		} else if row == r.rails-1 {
+			step = -1
+		}
+		row += step
+	}
+	var order []int
+	for _, positions := range rows {
+		order = append(order, positions...)
+	}
+	return order
+}
+
+func (r railFenceTransform) Inverse() (Transform, error) {
+	return railFenceTransform{rails: r.rails, decrypt: !r.decrypt}, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestCipherKnownValues(t *testing.T) {
+	tests := []struct {
+		spec, input, want string
+	}{
+		{"caesar:shift=3", "Abc xyz", "Def abc"},
+		{"caesar:shift=3", "Ґава, яр", "Єгдг, ву"},
+		{"atbash", "Abc xyz", "Zyx cba"},
+		{"atbash", "абв юя", "яюь ба"},
+		{"vigenere:key=key", "hello", "rijvs"},
+		{"railfence:rails=3", "abcdefg", "aebdfcg"},
+	}
+	for _, tt := range tests {
+		if got := pipelineOf(t, tt.spec).Apply(tt.input); got != tt.want {
+			t.Errorf("%s: %q became %q, want %q", tt.spec, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	specs := []string{"caesar:shift=7", "caesar:shift=-40", "vigenere:key=Ключ", "vigenere:key=lemon", "atbash", "railfence:rails=2", "railfence:rails=4"}
+	inputs := []string{
+		"Щастя, їжак і ґудзик — Євген співає п'ять пісень",
+		"The Quick Brown Fox jumps over the lazy dog, 123!",
+		"Київ and Lviv: 2 міста",
+		"",
+	}
+	for _, spec := range specs {
+		encode := pipelineOf(t, spec)
+		decode, err := encode.Inverse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range inputs {
+			encoded := encode.Apply(input)
+			if input != "" && encoded == input {
+				t.Errorf("%s: %q did not change", spec, input)
+			}
+			if decoded := decode.Apply(encoded); decoded != input {
+				t.Errorf("%s: %q encoded to %q decoded to %q", spec, input, encoded, decoded)
+			}
+		}
+	}
+}
+
+func TestCrackCaesar(t *testing.T) {
+	tests := []struct {
+		language, text string
+		shift          int
+	}{
+		{"uk", "Садок вишневий коло хати, хрущі над вишнями гудуть, плугатарі з плугами йдуть, " +
+			"співають ідучи дівчата, а матері вечерять ждуть.", 5},
+		{"en", "It was the best of times, it was the worst of times, it was the age of wisdom, " +
+			"it was the age of foolishness, it was the epoch of belief.", 11},
+	}
+	for _, tt := range tests {
+		encrypted := caesarTransform{shift: tt.shift}.Apply(tt.text)
+		counts := map[string]int{}
+		for _, r := range strings.ToLower(encrypted) {
+			if unicode.IsLetter(r) {
+				counts[string(r)]++
+			}
+		}
+		guesses, err := CrackCaesar(counts, tt.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if guesses[0].Shift != tt.shift {
+			t.Errorf("%s: best guess is shift %d, want %d (%v)", tt.language, guesses[0].Shift, tt.shift, guesses[:3])
+		}
+	}
+
+	if _, err := CrackCaesar(map[string]int{"1": 3}, "uk"); err == nil {
+		t.Error("a text without letters should not be cracked")
+	}
+	if _, err := CrackCaesar(map[string]int{"a": 3}, "de"); err == nil {
+		t.Error("an unknown language should be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config is the JSON configuration file of the text processor:
+//
+//	{"pipeline": [{"name": "double"}, {"name": "caesar", "params": {"shift": 3}}]}
+type Config struct {
+	Pipeline []StepConfig `json:"pipeline"`
+}
+
+// Params holds transform parameters; config files may give them as JSON
+// strings, numbers or booleans.
+type Params map[string]string
+
+func (p *Params) UnmarshalJSON(data []byte) error {
+	// Numbers keep their literal form, so large seeds are not rewritten in
+	// exponent notation.
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	*p = make(Params, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case string, json.Number, bool:
+			(*p)[key] = fmt.Sprint(value)
+		default:
+			return fmt.Errorf("параметр %q має бути рядком, числом або логічним значенням", key)
+		}
+	}
+	return nil
+}
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// letterFrequencies holds the relative frequencies of letters, in percent,
+// in English and Ukrainian texts.
+var letterFrequencies = map[string]map[rune]float64{
+	"en": {
+		'a': 8.17, 'b': 1.49, 'c': 2.78, 'd': 4.25, 'e': 12.70, 'f': 2.23, 'g': 2.02,
+		'h': 6.09, 'i': 6.97, 'j': 0.15, 'k': 0.77, 'l': 4.03, 'm': 2.41, 'n': 6.75,
+		'o': 7.51, 'p': 1.93, 'q': 0.10, 'r': 5.99, 's': 6.33, 't': 9.06, 'u': 2.76,
+		'v': 0.98, 'w': 2.36, 'x': 0.15, 'y': 1.97, 'z': 0.07,
+	},
+	"uk": {
+		'а': 8.04, 'б': 1.74, 'в': 5.36, 'г': 1.57, 'ґ': 0.02, 'д': 3.18, 'е': 4.77,
+		'є': 0.68, 'ж': 0.89, 'з': 2.07, 'и': 6.06, 'і': 5.51, 'ї': 0.69, 'й': 1.26,
+		'к': 3.94, 'л': 3.57, 'м': 3.19, 'н': 6.80, 'о': 9.34, 'п': 2.59, 'р': 4.79,
+		'с': 4.08, 'т': 5.20, 'у': 3.96, 'ф': 0.20, 'х': 1.16, 'ц': 0.94, 'ч': 1.35,
+		'ш': 0.76, 'щ': 0.62, 'ь': 1.79, 'ю': 0.82, 'я': 2.82,
+	},
+}
+
+var languageAlphabets = map[string][]rune{"en": latinAlphabet, "uk": ukrainianAlphabet}
+
+// CaesarGuess is a candidate Caesar shift with the chi-squared distance of
+// the decrypted letter counts from the language's frequencies; lower is
+// more likely.
+type CaesarGuess struct {
+	Shift      int
+	ChiSquared float64
+}
+
+// CrackCaesar ranks every shift of the language's alphabet by how well the
+// text would match the language once decrypted. counts maps lower-case
+// letters to their number in the encrypted text.
+func CrackCaesar(counts map[string]int, language string) ([]CaesarGuess, error) {
+	alphabet, ok := languageAlphabets[language]
+	if !ok {
+		return nil, fmt.Errorf("невідома мова %q (en або uk)", language)
+	}
+	frequencies := letterFrequencies[language]
+
+	observed := make([]float64, len(alphabet))
+	total, sum := 0.0, 0.0
+	for i, letter := range alphabet {
+		observed[i] = float64(counts[string(letter)])
+		total += observed[i]
+		sum += frequencies[letter]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("текст не містить жодної літери мови %q", language)
+	}
+
+	n := len(alphabet)
+	guesses := make([]CaesarGuess, n)
+	for shift := 0; shift < n; shift++ {
+		chi := 0.0
+		for j, letter := range alphabet {
+			expected := total * frequencies[letter] / sum
+			o := observed[(j+shift)%n]
+			chi += (o - expected) * (o - expected) / expected
+		}
+		guesses[shift] = CaesarGuess{Shift: shift, ChiSquared: chi}
+	}
+	sort.Slice(guesses, func(i, j int) bool { return guesses[i].ChiSquared < guesses[j].ChiSquared })
+	return guesses, nil
+}
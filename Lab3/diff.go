@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DiffFormat selects how a dry run shows the changes.
+type DiffFormat int
+
+const (
+	UnifiedDiff DiffFormat = iota
+	SideBySideDiff
+)
+
+func ParseDiffFormat(name string) (DiffFormat, error) {
+	switch name {
+	case "unified":
+		return UnifiedDiff, nil
+	case "side-by-side":
+		return SideBySideDiff, nil
+	default:
+		return 0, fmt.Errorf("невідомий формат різниці %q (unified або side-by-side)", name)
+	}
+}
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorBold  = "\033[1m"
+	colorReset = "\033[0m"
+)
+
+// sideBySideWidth is the display width of each column of a side-by-side
+// diff, and sideBySideNumberWidth that of the line numbers.
+const (
+	sideBySideWidth       = 60
+	sideBySideNumberWidth = 6
+)
+
+// diffContext is the number of unchanged lines around the changes of a
+// unified diff, as in diff -u.
+const diffContext = 3
+
+// diffSpillLimit is how many bytes of a hunk are held in memory; the rest
+// goes to a temporary file until the hunk is complete.
+var diffSpillLimit = 1 << 20
+
+// diffWriter writes the changed lines of files, with ANSI colors when
+// color is set.
+type diffWriter struct {
+	w      io.Writer
+	format DiffFormat
+	color  bool
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is not set.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (d diffWriter) paint(color, text string) string {
+	if !d.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// File starts the diff of the file at input that would be written to
+// output. The lines are passed to Line as they are processed and Finish
+// ends the diff; only a few lines of context and the current hunk are kept,
+// so the diff of a large file does not have to fit in memory.
+//
+// The unified format is that of diff -u, which patch can apply; the
+// side-by-side format leaves unchanged lines out.
+func (d diffWriter) File(input, output string) *fileDiff {
+	return &fileDiff{diffWriter: d, input: input, output: output}
+}
+
+// fileDiff is the diff of one file, written as its lines arrive.
+type fileDiff struct {
+	diffWriter
+	input, output string
+	started       bool
+
+	// last is the latest line, held back until it is known whether it is
+	// the last line of the file.
+	last    LineDiff
+	hasLast bool
+
+	// context holds the unchanged lines after the latest change, or the
+	// lines before the next change when no hunk is open; at most
+	// 2*diffContext+1 of them are kept.
+	context []LineDiff
+	// The open hunk: its first line, its length so far, its rendered lines
+	// and the "+" lines of the run of changes being read.
+	hunkStart, hunkLines int
+	body, added          spillBuffer
+}
+
+// Line adds the next line of the file.
+func (f *fileDiff) Line(line LineDiff) {
+	if f.hasLast {
+		f.add(f.last, false)
+	}
+	f.last, f.hasLast = line, true
+}
+
+// Finish writes what is left of the diff; unterminated tells whether the
+// file ends without a newline.
+func (f *fileDiff) Finish(unterminated bool) {
+	if f.hasLast {
+		f.add(f.last, unterminated)
+		f.hasLast = false
+	}
+	if f.hunkLines > 0 {
+		f.closeHunk()
+	}
+	f.body.Close()
+	f.added.Close()
+}
+
+func (f *fileDiff) start() {
+	if f.started {
+		return
+	}
+	f.started = true
+	if f.format == SideBySideDiff {
+		header := fmt.Sprintf("%*s  %s │ %s", sideBySideNumberWidth, "", fitColumn(f.input, sideBySideWidth), f.output)
+		fmt.Fprintln(f.w, f.paint(colorBold, header))
+		return
+	}
+	fmt.Fprintln(f.w, f.paint(colorBold, "--- "+f.input))
+	fmt.Fprintln(f.w, f.paint(colorBold, "+++ "+f.output))
+}
+
+func (f *fileDiff) add(line LineDiff, noNewline bool) {
+	changed := line.Before != line.After
+	if f.format == SideBySideDiff {
+		if changed {
+			f.start()
+			fmt.Fprintf(f.w, "%*d  %s │ %s\n", sideBySideNumberWidth, line.Line,
+				f.paint(colorRed, fitColumn(line.Before, sideBySideWidth)),
+				f.paint(colorGreen, line.After))
+		}
+		return
+	}
+
+	if !changed {
+		f.flushAdded()
+		f.context = append(f.context, line)
+		switch {
+		case f.hunkLines > 0 && len(f.context) > 2*diffContext:
+			// The next change is too far away to share this hunk.
+			f.closeHunk()
+		case f.hunkLines == 0 && len(f.context) > diffContext:
+			f.context = f.context[1:]
+		}
+		if noNewline && f.hunkLines > 0 && len(f.context) <= diffContext {
+			f.flushContext(len(f.context), true)
+		}
+		return
+	}
+
+	if f.hunkLines == 0 {
+		f.start()
+		f.hunkStart = line.Line - len(f.context)
+	}
+	f.flushContext(len(f.context), false)
+	f.body.WriteString(f.paint(colorRed, "-"+line.Before) + "\n")
+	f.added.WriteString(f.paint(colorGreen, "+"+line.After) + "\n")
+	if noNewline {
+		f.body.WriteString(noNewlineMarker)
+		f.added.WriteString(noNewlineMarker)
+	}
+	f.hunkLines++
+}
+
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// flushContext moves the first n context lines into the hunk; the last of
+// them ends the file without a newline when noNewline is set.
+func (f *fileDiff) flushContext(n int, noNewline bool) {
+	for _, line := range f.context[:n] {
+		f.body.WriteString(" " + line.Before + "\n")
+	}
+	if noNewline {
+		f.body.WriteString(noNewlineMarker)
+	}
+	f.hunkLines += n
+	f.context = append(f.context[:0], f.context[n:]...)
+}
+
+// flushAdded ends a run of changes: its new lines follow its old ones.
+func (f *fileDiff) flushAdded() {
+	f.added.WriteTo(&f.body)
+}
+
+// closeHunk writes the open hunk with up to diffContext lines of trailing
+// context and keeps the rest of the context for the next hunk.
+func (f *fileDiff) closeHunk() {
+	f.flushAdded()
+	f.flushContext(min(len(f.context), diffContext), false)
+	// Line numbers count from 1; diff omits a count of 1.
+	lineRange := hunkRange(f.hunkStart, f.hunkLines)
+	fmt.Fprintln(f.w, f.paint(colorCyan, fmt.Sprintf("@@ -%s +%s @@", lineRange, lineRange)))
+	f.body.WriteTo(f.w)
+	f.hunkLines = 0
+	if len(f.context) > diffContext {
+		f.context = f.context[len(f.context)-diffContext:]
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// spillBuffer collects text in memory up to diffSpillLimit bytes and in a
+// temporary file beyond that. Should the file fail, the text stays in
+// memory.
+type spillBuffer struct {
+	mem  bytes.Buffer
+	file *os.File
+}
+
+func (b *spillBuffer) WriteString(s string) {
+	if b.file == nil && b.mem.Len()+len(s) > diffSpillLimit {
+		if file, err := os.CreateTemp("", "lab3-diff-*"); err == nil {
+			b.file = file
+			b.mem.WriteTo(file)
+		}
+	}
+	if b.file != nil {
+		b.file.WriteString(s)
+		return
+	}
+	b.mem.WriteString(s)
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	b.WriteString(string(p))
+	return len(p), nil
+}
+
+// WriteTo moves the collected text to w and empties the buffer.
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return b.mem.WriteTo(w)
+	}
+	defer b.Close()
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, b.file)
+}
+
+// Close removes the temporary file, if any.
+func (b *spillBuffer) Close() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+	b.mem.Reset()
+}
+
+// fitColumn pads or cuts text to width terminal columns. Wide characters
+// (CJK, Hangul, fullwidth forms and most emoji) take two columns; the
+// East Asian Width property is approximated by its main blocks, so rare
+// wide or ambiguous characters may still shift the columns.
+func fitColumn(text string, width int) string {
+	var b strings.Builder
+	used := 0
+	letters := graphemes(text)
+	for i, letter := range letters {
+		w := displayWidth(letter)
+		// Keep room for the ellipsis unless the rest fits.
+		if used+w > width || (used+w == width && i < len(letters)-1) {
+			b.WriteString("…")
+			used++
+			break
+		}
+		b.WriteString(letter)
+		used += w
+	}
+	return b.String() + strings.Repeat(" ", max(width-used, 0))
+}
+
+// displayWidth is the number of terminal columns a grapheme cluster takes.
+func displayWidth(cluster string) int {
+	r := firstRune(cluster)
+	switch {
+	case isGraphemeControl(r) || isGraphemeExtend(r) || r == zeroWidthJoiner:
+		return 0
+	case isWide(r), isRegionalIndicator(r), strings.ContainsRune(cluster, '\uFE0F'):
+		return 2
+	}
+	return 1
+}
+
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) || (r >= 0x2E80 && r <= 0x303E) ||
+		(r >= 0x3041 && r <= 0x33FF) || (r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x4E00 && r <= 0x9FFF) || (r >= 0xA000 && r <= 0xA4CF) ||
+		(r >= 0xAC00 && r <= 0xD7A3) || (r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0xFE30 && r <= 0xFE4F) || (r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6) || (r >= 0x1F300 && r <= 0x1F64F) ||
+		(r >= 0x1F900 && r <= 0x1F9FF) || (r >= 0x20000 && r <= 0x3FFFD)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// writeDiff feeds the lines to a diff of in.txt and out.txt.
+func writeDiff(d diffWriter, before, after []string, unterminated bool) {
+	fd := d.File("in.txt", "out.txt")
+	for i := range before {
+		fd.Line(LineDiff{Line: i + 1, Before: before[i], After: after[i]})
+	}
+	fd.Finish(unterminated)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := strings.Split("aa b c d e f g h kk", " ")
+	after := strings.Split("+ b c d e f g h +", " ")
+	tests := []struct {
+		name         string
+		before       []string
+		after        []string
+		unterminated bool
+		want         string
+	}{
+		{"unchanged", before, before, false, ""},
+		{"separate hunks", before, after, true, `--- in.txt
++++ out.txt
+@@ -1,4 +1,4 @@
+-aa
+++
+ b
+ c
+ d
+@@ -6,4 +6,4 @@
+ f
+ g
+ h
+-kk
+\ No newline at end of file
+++
+\ No newline at end of file
+`},
+		// Changes whose contexts meet share a hunk.
+		{"merged hunk", before[:8], append([]string{"+"}, append(before[1:7:7], "x")...), false, `--- in.txt
++++ out.txt
+@@ -1,8 +1,8 @@
+-aa
+++
+ b
+ c
+ d
+ e
+ f
+ g
+-h
++x
+`},
+		{"single line", []string{"a", "b"}, []string{"a", "c"}, false, `--- in.txt
++++ out.txt
+@@ -1,2 +1,2 @@
+ a
+-b
++c
+`},
+		{"run of changes", []string{"a", "b", "c"}, []string{"x", "y", "c"}, false, `--- in.txt
++++ out.txt
+@@ -1,3 +1,3 @@
+-a
+-b
++x
++y
+ c
+`},
+		{"unterminated context", []string{"a", "b"}, []string{"x", "b"}, true, `--- in.txt
++++ out.txt
+@@ -1,2 +1,2 @@
+-a
++x
+ b
+\ No newline at end of file
+`},
+		{"unterminated beyond context", strings.Split("a b c d e", " "), strings.Split("x b c d e", " "), true, `--- in.txt
++++ out.txt
+@@ -1,4 +1,4 @@
+-a
++x
+ b
+ c
+ d
+`},
+		{"one line file", []string{"a"}, []string{"b"}, false, `--- in.txt
++++ out.txt
+@@ -1 +1 @@
+-a
++b
+`},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		writeDiff(diffWriter{w: &out, format: UnifiedDiff}, tt.before, tt.after, tt.unterminated)
+		if out.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out.String(), tt.want)
+		}
+
+		// A hunk larger than the memory limit goes through a temporary file.
+		limit := diffSpillLimit
+		diffSpillLimit = 4
+		out.Reset()
+		writeDiff(diffWriter{w: &out, format: UnifiedDiff}, tt.before, tt.after, tt.unterminated)
+		diffSpillLimit = limit
+		if out.String() != tt.want {
+			t.Errorf("%s, spilled: got\n%s\nwant\n%s", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+func TestUnifiedDiffLongFile(t *testing.T) {
+	// Only the hunks around the changes of a long file are written.
+	var before, after []string
+	for i := range 10000 {
+		line := fmt.Sprint(i)
+		before = append(before, line)
+		if i == 5000 {
+			line = "x"
+		}
+		after = append(after, line)
+	}
+	var out strings.Builder
+	writeDiff(diffWriter{w: &out, format: UnifiedDiff}, before, after, false)
+	want := `--- in.txt
++++ out.txt
+@@ -4998,7 +4998,7 @@
+ 4997
+ 4998
+ 4999
+-5000
++x
+ 5001
+ 5002
+ 5003
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"кіт", 5, "кіт  "},
+		{"довгий рядок", 6, "довги…"},
+		{"日本語", 7, "日本語 "},
+		{"日本語", 5, "日本…"},
+		{"a😀b", 5, "a😀b "},
+		{"🇺🇦", 3, "🇺🇦 "},
+	}
+	for _, tt := range tests {
+		if got := fitColumn(tt.text, tt.width); got != tt.want {
+			t.Errorf("fitColumn(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// document transforms the human text of a structured format one line at a
+// time. Implementations keep state between lines, such as being inside a
+// code block, so one document serves exactly one file.
+type document interface {
+	Line(line string, transform func(string) string) string
+}
+
+var documentFormats = map[string]func() document{
+	"markdown": func() document { return &markdownDocument{} },
+	"html":     func() document { return &htmlDocument{} },
+	"json":     func() document { return jsonDocument{} },
+}
+
+var formatExtensions = map[string]string{
+	".md": "markdown", ".markdown": "markdown",
+	".html": "html", ".htm": "html",
+	".json": "json",
+}
+
+// ParseFormat checks a -format value: auto, text or a document format.
+func ParseFormat(name string) (string, error) {
+	if name == "auto" || name == "text" || documentFormats[name] != nil {
+		return name, nil
+	}
+	return "", fmt.Errorf("невідомий формат %q (auto, text, markdown, html або json)", name)
+}
+
+// formatOf resolves the auto format from the file extension.
+func formatOf(format, path string) string {
+	if format != "auto" && format != "" {
+		return format
+	}
+	if name, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return name
+	}
+	return "text"
+}
+
+// urlPrefixes start the bare URLs and addresses left as they are in text.
+var urlPrefixes = []string{"http://", "https://", "ftp://", "mailto:", "www."}
+
+// transformText transforms text except for the URLs in it.
+func transformText(text string, transform func(string) string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if i > 0 && !isSpaceByte(text[i-1]) && text[i-1] != '(' {
+			continue
+		}
+		end := urlEnd(text, i)
+		if end == i {
+			continue
+		}
+		if start < i {
+			b.WriteString(transform(text[start:i]))
+		}
+		b.WriteString(text[i:end])
+		start, i = end, end-1
+	}
+	if start < len(text) {
+		b.WriteString(transform(text[start:]))
+	}
+	return b.String()
+}
+
+// urlEnd returns the end of a URL starting at i, or i when there is none.
+// Trailing punctuation is left to the text.
+func urlEnd(text string, i int) int {
+	hasPrefix := false
+	for _, prefix := range urlPrefixes {
+		if len(text)-i > len(prefix) && strings.EqualFold(text[i:i+len(prefix)], prefix) {
+			hasPrefix = true
+			break
+		}
+	}
+	if !hasPrefix {
+		return i
+	}
+	end := i
+	for end < len(text) && !isSpaceByte(text[end]) && text[end] != '<' && text[end] != '"' {
+		end++
+	}
+	for end > i && strings.IndexByte(".,;:!?)'", text[end-1]) >= 0 {
+		end--
+	}
+	return end
+}
+
+func isURL(s string) bool {
+	return urlEnd(s, 0) == len(s) && s != ""
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// markdownDocument leaves fenced and indented code, code spans, link
+// destinations, reference definitions, autolinks, URLs and HTML untouched.
+type markdownDocument struct {
+	fence     string // the opening fence while inside a fenced code block
+	prevBlank bool
+	prevCode  bool
+	html      htmlDocument
+}
+
+func (m *markdownDocument) Line(line string, transform func(string) string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(line) - len(trimmed)
+	blank := strings.TrimSpace(line) == ""
+	defer func() { m.prevBlank = blank }()
+
+	if m.fence != "" {
+		if indent < 4 && strings.HasPrefix(trimmed, m.fence) && strings.Trim(trimmed, m.fence[:1]+" ") == "" {
+			m.fence = ""
+		}
+		return line
+	}
+	if indent < 4 {
+		if fence := fenceOf(trimmed); fence != "" {
+			m.fence = fence
+			return line
+		}
+	}
+
+	code := (indent >= 4 || strings.HasPrefix(line, "\t")) && !blank && (m.prevBlank || m.prevCode)
+	m.prevCode = code || (m.prevCode && blank)
+	switch {
+	case code:
+		return line
+	case indent < 4 && isReferenceDefinition(trimmed):
+		return line
+	case m.html.inMarkup() || (indent < 4 && strings.HasPrefix(trimmed, "<")):
+		return m.html.Line(line, transform)
+	}
+	marker := blockMarkerEnd(line)
+	return line[:marker] + markdownInline(line[marker:], transform)
+}
+
+// blockMarkerEnd returns the length of the block syntax that starts line:
+// quote markers, list bullets and numbers, task boxes and heading marks.
+func blockMarkerEnd(line string) int {
+	end, listed := 0, false
+	for {
+		i := end
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		rest := line[i:]
+		switch {
+		case strings.HasPrefix(rest, ">"):
+			end, listed = i+1, false
+			continue
+		case listed && len(rest) >= 3 && (rest[:3] == "[ ]" || rest[:3] == "[x]" || rest[:3] == "[X]") && markerEnds(rest, 3):
+			end, listed = i+3, false
+			continue
+		}
+		n := len(rest) - len(strings.TrimLeft(rest, "#"))
+		if n >= 1 && n <= 6 && markerEnds(rest, n) {
+			return i + n
+		}
+		if len(rest) > 0 && strings.IndexByte("-*+", rest[0]) >= 0 && markerEnds(rest, 1) {
+			end, listed = i+1, true
+			continue
+		}
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits >= 1 && digits <= 9 && len(rest) > digits && (rest[digits] == '.' || rest[digits] == ')') && markerEnds(rest, digits+1) {
+			end, listed = i+digits+1, true
+			continue
+		}
+		return end
+	}
+}
+
+// markerEnds reports whether a block marker of length n is followed by
+// white space or the end of the line, as Markdown requires.
+func markerEnds(s string, n int) bool {
+	return len(s) == n || s[n] == ' ' || s[n] == '\t'
+}
+
+// fenceOf returns the fence that opens a fenced code block, if line does.
+func fenceOf(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+func isReferenceDefinition(line string) bool {
+	if !strings.HasPrefix(line, "[") {
+		return false
+	}
+	end := strings.Index(line, "]:")
+	return end > 1 && !strings.Contains(line[:end], "]")
+}
+
+// markdownInline transforms the text of one line of Markdown.
+func markdownInline(line string, transform func(string) string) string {
+	var b strings.Builder
+	text := 0
+	flush := func(i int) {
+		if text < i {
+			b.WriteString(transformText(line[text:i], transform))
+		}
+	}
+	for i := 0; i < len(line); {
+		verbatim, end := 0, 0
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			verbatim = i + 2
+		case c == '`':
+			n := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+			if close := strings.Index(line[i+n:], line[i:i+n]); close >= 0 {
+				verbatim = i + n + close + n
+			}
+		case c == '<':
+			if close := strings.IndexByte(line[i:], '>'); close > 1 && !strings.ContainsAny(line[i:i+close], " \t") || close > 1 && isTagStart(line[i+1]) {
+				verbatim = i + close + 1
+			}
+		case c == '[' || (c == '!' && i+1 < len(line) && line[i+1] == '['):
+			open := i
+			if c == '!' {
+				open++
+			}
+			if label, rest, ok := linkParts(line, open); ok {
+				flush(i)
+				b.WriteString(line[i : open+1])
+				b.WriteString(markdownInline(line[open+1:label], transform))
+				b.WriteString(line[label:rest])
+				text, i = rest, rest
+				continue
+			}
+		}
+		if end = urlEnd(line, i); end > i && (i == 0 || isSpaceByte(line[i-1]) || line[i-1] == '(') {
+			verbatim = end
+		}
+		if verbatim > i {
+			flush(i)
+			b.WriteString(line[i:verbatim])
+			text, i = verbatim, verbatim
+			continue
+		}
+		i++
+	}
+	flush(len(line))
+	return b.String()
+}
+
+// linkParts finds the closing bracket of the label opened at open and the
+// end of the destination "(...)" or reference "[...]" after it.
+func linkParts(line string, open int) (label, rest int, ok bool) {
+	depth := 0
+	for i := open; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 < len(line) && (line[i+1] == '(' || line[i+1] == '[') {
+				closer := ")"
+				if line[i+1] == '[' {
+					closer = "]"
+				}
+				if end := strings.Index(line[i+1:], closer); end >= 0 {
+					return i, i + 1 + end + 1, true
+				}
+			}
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// rawElements hold text that must not be transformed.
+var rawElements = []string{"script", "style", "pre", "code", "textarea"}
+
+// htmlDocument leaves tags with their attributes, comments, entities and
+// the contents of script, style, pre, code and textarea untouched.
+type htmlDocument struct {
+	mode    int    // htmlText, htmlTag, htmlComment or htmlRaw
+	quote   byte   // the quote of an attribute value open inside a tag
+	raw     string // the element whose contents are raw
+	pending string // a raw element whose start tag is being read
+}
+
+const (
+	htmlText = iota
+	htmlTag
+	htmlComment
+	htmlRaw
+)
+
+func (h *htmlDocument) inMarkup() bool {
+	return h.mode != htmlText
+}
+
+func (h *htmlDocument) Line(line string, transform func(string) string) string {
+	var b strings.Builder
+	text := 0
+	flush := func(i int) {
+		if text < i {
+			b.WriteString(transformText(line[text:i], transform))
+		}
+		text = i
+	}
+
+	for i := 0; i < len(line); i++ {
+		switch h.mode {
+		case htmlText:
+			switch line[i] {
+			case '<':
+				if i+1 < len(line) && !isTagStart(line[i+1]) {
+					continue
+				}
+				flush(i)
+				if strings.HasPrefix(line[i:], "<!--") {
+					h.mode = htmlComment
+					i += 3
+					continue
+				}
+				h.mode = htmlTag
+				h.pending = rawElementAt(line[i+1:])
+			case '&':
+				if end := strings.IndexByte(line[i:], ';'); end > 1 && isEntityName(line[i+1:i+end]) {
+					flush(i)
+					b.WriteString(line[i : i+end+1])
+					i += end
+					text = i + 1
+				}
+			}
+		case htmlTag:
+			switch {
+			case h.quote != 0:
+				if line[i] == h.quote {
+					h.quote = 0
+				}
+			case line[i] == '"' || line[i] == '\'':
+				h.quote = line[i]
+			case line[i] == '>':
+				h.mode = htmlText
+				if h.pending != "" && !strings.HasSuffix(line[:i], "/") {
+					h.mode, h.raw = htmlRaw, h.pending
+				}
+				h.pending = ""
+				b.WriteString(line[text : i+1])
+				text = i + 1
+			}
+		case htmlComment:
+			if strings.HasPrefix(line[i:], "-->") {
+				h.mode = htmlText
+				b.WriteString(line[text : i+3])
+				i += 2
+				text = i + 1
+			}
+		case htmlRaw:
+			if strings.HasPrefix(strings.ToLower(line[i:]), "</"+h.raw) {
+				b.WriteString(line[text:i])
+				text = i
+				h.mode, h.raw = htmlTag, ""
+			}
+		}
+	}
+
+	if h.mode == htmlText {
+		flush(len(line))
+	} else {
+		b.WriteString(line[text:])
+	}
+	return b.String()
+}
+
+// rawElementAt returns the raw element named by a start tag, if any.
+func rawElementAt(tag string) string {
+	tag = strings.ToLower(tag)
+	for _, name := range rawElements {
+		if strings.HasPrefix(tag, name) && (len(tag) == len(name) || strings.IndexByte(" \t>/", tag[len(name)]) >= 0) {
+			return name
+		}
+	}
+	return ""
+}
+
+func isEntityName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '#' && i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonDocument transforms string values, leaving keys, numbers, literals
+// and strings that are URLs untouched. JSON strings cannot span lines, so
+// it needs no state.
+type jsonDocument struct{}
+
+func (jsonDocument) Line(line string, transform func(string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] != '"' {
+			b.WriteByte(line[i])
+			continue
+		}
+		end := i + 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			b.WriteString(line[i:])
+			break
+		}
+		literal := line[i : end+1]
+		i = end
+		if isJSONKey(line[end+1:]) {
+			b.WriteString(literal)
+			continue
+		}
+		var value string
+		if err := json.Unmarshal([]byte(literal), &value); err != nil || isURL(value) {
+			b.WriteString(literal)
+			continue
+		}
+		if changed := transformText(value, transform); changed != value {
+			literal = jsonString(changed)
+		}
+		b.WriteString(literal)
+	}
+	return b.String()
+}
+
+func isJSONKey(rest string) bool {
+	return strings.HasPrefix(strings.TrimLeft(rest, " \t"), ":")
+}
+
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// pipelineOf builds a pipeline from a -pipeline spec.
+func pipelineOf(t *testing.T, spec string) Pipeline {
+	t.Helper()
+	steps, err := ParsePipeline(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := BuildPipeline(steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pipeline
+}
+
+// processDocument runs text through the pipeline as the file name would be.
+func processDocument(t *testing.T, name, spec, text string) string {
+	t.Helper()
+	p := (&Processor{Pipeline: pipelineOf(t, spec), Format: "auto"}).forFile(name)
+	var out strings.Builder
+	if _, err := p.Process(strings.NewReader(text), &out, 0); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestMarkdownDocument(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"heading", "# Привіт світ", "# тівирП тівс"},
+		{"not a heading", "#тег", "#гет"},
+		{"ordered list", "10. Десятий пункт", "10. йитясеД ткнуп"},
+		{"ordered list with paren", "2) Другий", "2) йигурД"},
+		{"bullet", "- пункт", "- ткнуп"},
+		{"nested bullet", "  * пункт", "  * ткнуп"},
+		{"emphasis is not a bullet", "*слово*", "*оволс*"},
+		{"task", "- [x] Зроблено", "- [x] онелборЗ"},
+		{"quote", "> ## Цитата", "> ## ататиЦ"},
+		{"quoted list", "> 1. пункт", "> 1. ткнуп"},
+		{"code span", "текст `код тут` текст", "тскет `код тут` тскет"},
+		{"link", "[посилання](https://example.com/шлях)", "[янналисоп](https://example.com/шлях)"},
+		{"reference link", "[текст][ref]", "[тскет][ref]"},
+		{"autolink", "див <https://go.dev>", "вид <https://go.dev>"},
+		{"bare url", "див https://go.dev/doc, так", "вид https://go.dev/doc, кат"},
+		{"reference definition", "[ref]: https://example.com Назва", "[ref]: https://example.com Назва"},
+		{"fenced code", "```\nкод\n```\nтекст", "```\nкод\n```\nтскет"},
+		{"indented code", "текст\n\n    код\n", "тскет\n\n    код\n"},
+		{"html block", "<div class=\"a\">текст</div>", "<div class=\"a\">тскет</div>"},
+	}
+	for _, tt := range tests {
+		if got := processDocument(t, "test.md", "reverse", tt.in); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLDocument(t *testing.T) {
+	in := "<p class=\"x\" title=\"a > b\">Добрий день &nbsp; світе</p>\n" +
+		"<script>\nvar s = \"не чіпати\";\n</script>\n" +
+		"<!-- коментар\n-->\n<pre>код</pre><a href=\"http://x.y\">лінк</a>"
+	want := "<p class=\"x\" title=\"a > b\">ЙИРБОД ЬНЕД &nbsp; ЕТІВС</p>\n" +
+		"<script>\nvar s = \"не чіпати\";\n</script>\n" +
+		"<!-- коментар\n-->\n<pre>код</pre><a href=\"http://x.y\">КНІЛ</a>"
+	if got := processDocument(t, "test.html", "reverse,upper", in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSONDocument(t *testing.T) {
+	in := `{"title": "Добрий день", "url": "https://example.com", "n": 42, "list": ["два \"три\"", "aб"]}`
+	want := `{"title": "ДОБРИЙ ДЕНЬ", "url": "https://example.com", "n": 42, "list": ["ДВА \"ТРИ\"", "AБ"]}`
+	if got := processDocument(t, "test.json", "upper", in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a text encoding Lab3 can read and write: UTF-8, UTF-16 in
+// either byte order, or a single-byte Cyrillic code page.
+type Encoding struct {
+	Name  string
+	high  *[128]rune // single-byte encodings: the characters of bytes 0x80-0xFF
+	order byteOrder  // UTF-16 encodings
+}
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+var (
+	UTF8        = &Encoding{Name: "utf-8"}
+	UTF16LE     = &Encoding{Name: "utf-16le", order: binary.LittleEndian}
+	UTF16BE     = &Encoding{Name: "utf-16be", order: binary.BigEndian}
+	Windows1251 = &Encoding{Name: "windows-1251", high: &windows1251High}
+	KOI8U       = &Encoding{Name: "koi8-u", high: &koi8uHigh}
+)
+
+var encodingNames = map[string]*Encoding{
+	"utf-8": UTF8, "utf8": UTF8,
+	"utf-16le": UTF16LE, "utf-16": UTF16LE, "utf16": UTF16LE,
+	"utf-16be":     UTF16BE,
+	"windows-1251": Windows1251, "cp1251": Windows1251,
+	"koi8-u": KOI8U, "koi8u": KOI8U,
+}
+
+// LookupEncoding finds an encoding by name, ignoring case.
+func LookupEncoding(name string) (*Encoding, error) {
+	if enc, ok := encodingNames[strings.ToLower(name)]; ok {
+		return enc, nil
+	}
+	var names []string
+	for _, enc := range []*Encoding{UTF8, UTF16LE, UTF16BE, Windows1251, KOI8U} {
+		names = append(names, enc.Name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("невідоме кодування %q (доступні: %s)", name, strings.Join(names, ", "))
+}
+
+// detectionSample is how much of the input DetectEncoding looks at.
+const detectionSample = 64 * 1024
+
+// DetectEncoding guesses the encoding of text from its beginning and
+// returns the length of the byte order mark, if any. A BOM decides; without
+// one, valid UTF-8 wins, zero bytes in every other position mean UTF-16,
+// and otherwise the single-byte code page that yields more of the most
+// frequent Ukrainian letters is chosen.
+func DetectEncoding(sample []byte) (enc *Encoding, bom int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return UTF8, 3
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return UTF16LE, 2
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return UTF16BE, 2
+	}
+
+	// The sample may end in the middle of a character.
+	valid := sample
+	if start := lastRuneStart(valid); !utf8.FullRune(valid[start:]) {
+		valid = valid[:start]
+	}
+	if utf8.Valid(valid) && bytes.IndexByte(sample, 0) < 0 {
+		return UTF8, 0
+	}
+
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	if pairs := len(sample) / 2; pairs > 0 {
+		switch {
+		case oddZeros*4 > pairs && oddZeros > evenZeros*4:
+			return UTF16LE, 0
+		case evenZeros*4 > pairs && evenZeros > oddZeros*4:
+			return UTF16BE, 0
+		}
+	}
+
+	if letterScore(sample, KOI8U) > letterScore(sample, Windows1251) {
+		return KOI8U, 0
+	}
+	return Windows1251, 0
+}
+
+func lastRuneStart(b []byte) int {
+	i := len(b) - 1
+	for i > 0 && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return max(i, 0)
+}
+
+// letterScore counts the most frequent Ukrainian letters in sample decoded
+// as enc.
+func letterScore(sample []byte, enc *Encoding) int {
+	score := 0
+	for _, b := range sample {
+		if b >= 0x80 && strings.ContainsRune("оанітеирвслкдмпу", enc.high[b-0x80]) {
+			score++
+		}
+	}
+	return score
+}
+
+// decodingReader converts text in a non-UTF-8 encoding to UTF-8 as it is
+// read. consumed counts the source bytes read so far.
+type decodingReader struct {
+	src      *bufio.Reader
+	enc      *Encoding
+	pending  []byte
+	consumed int64
+	err      error
+}
+
+func newDecodingReader(r *bufio.Reader, enc *Encoding) *decodingReader {
+	return &decodingReader{src: r, enc: enc}
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.pending) < len(p) && d.err == nil {
+		var r rune
+		r, d.err = d.next()
+		if d.err == nil {
+			d.pending = utf8.AppendRune(d.pending, r)
+		}
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	if n == 0 && d.err != nil {
+		return 0, d.err
+	}
+	return n, nil
+}
+
+func (d *decodingReader) next() (rune, error) {
+	if d.enc.high != nil {
+		b, err := d.src.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		d.consumed++
+		if b < 0x80 {
+			return rune(b), nil
+		}
+		return d.enc.high[b-0x80], nil
+	}
+
+	unit, err := d.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(rune(unit)) {
+		return rune(unit), nil
+	}
+	if next, _ := d.src.Peek(2); len(next) == 2 {
+		if r := utf16.DecodeRune(rune(unit), rune(d.enc.order.Uint16(next))); r != utf8.RuneError {
+			d.readUnit()
+			return r, nil
+		}
+	}
+	return utf8.RuneError, nil
+}
+
+func (d *decodingReader) readUnit() (uint16, error) {
+	var unit [2]byte
+	n, err := io.ReadFull(d.src, unit[:])
+	d.consumed += int64(n)
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		// A trailing odd byte is not a character.
+		return utf8.RuneError, nil
+	case err != nil:
+		return 0, err
+	}
+	return d.enc.order.Uint16(unit[:]), nil
+}
+
+// encodingWriter converts UTF-8 written to it into another encoding.
+// Characters the encoding cannot represent are written as '?'.
+type encodingWriter struct {
+	w       io.Writer
+	enc     *Encoding
+	pending []byte
+	reverse map[rune]byte
+	bom     bool
+}
+
+// newEncodingWriter converts to enc; UTF-16 output starts with a byte
+// order mark.
+func newEncodingWriter(w io.Writer, enc *Encoding) *encodingWriter {
+	e := &encodingWriter{w: w, enc: enc, bom: enc.order != nil}
+	if enc.high != nil {
+		e.reverse = make(map[rune]byte, 128)
+		for i, r := range enc.high {
+			if r != utf8.RuneError {
+				e.reverse[r] = byte(0x80 + i)
+			}
+		}
+	}
+	return e
+}
+
+func (e *encodingWriter) Write(p []byte) (int, error) {
+	e.pending = append(e.pending, p...)
+	var out []byte
+	if e.bom {
+		out = e.enc.order.AppendUint16(out, 0xFEFF)
+		e.bom = false
+	}
+	for len(e.pending) > 0 && utf8.FullRune(e.pending) {
+		r, size := utf8.DecodeRune(e.pending)
+		e.pending = e.pending[size:]
+		out = e.appendRune(out, r)
+	}
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes an incomplete character left at the end as '?'.
+func (e *encodingWriter) Flush() error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	e.pending = nil
+	_, err := e.w.Write(e.appendRune(nil, '?'))
+	return err
+}
+
+func (e *encodingWriter) appendRune(out []byte, r rune) []byte {
+	if e.enc.order != nil {
+		for _, unit := range utf16.AppendRune(nil, r) {
+			out = e.enc.order.AppendUint16(out, unit)
+		}
+		return out
+	}
+	if r < 0x80 {
+		return append(out, byte(r))
+	}
+	if b, ok := e.reverse[r]; ok {
+		return append(out, b)
+	}
+	return append(out, '?')
+}
+
+var windows1251High = [128]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0xFFFD, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7,
+	0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7,
+	0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+	0x0410, 0x0411, 0x0412, 0x0413, 0x0414, 0x0415, 0x0416, 0x0417,
+	0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E, 0x041F,
+	0x0420, 0x0421, 0x0422, 0x0423, 0x0424, 0x0425, 0x0426, 0x0427,
+	0x0428, 0x0429, 0x042A, 0x042B, 0x042C, 0x042D, 0x042E, 0x042F,
+	0x0430, 0x0431, 0x0432, 0x0433, 0x0434, 0x0435, 0x0436, 0x0437,
+	0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E, 0x043F,
+	0x0440, 0x0441, 0x0442, 0x0443, 0x0444, 0x0445, 0x0446, 0x0447,
+	0x0448, 0x0449, 0x044A, 0x044B, 0x044C, 0x044D, 0x044E, 0x044F,
+}
+
+var koi8uHigh = [128]rune{
+	0x2500, 0x2502, 0x250C, 0x2510, 0x2514, 0x2518, 0x251C, 0x2524,
+	0x252C, 0x2534, 0x253C, 0x2580, 0x2584, 0x2588, 0x258C, 0x2590,
+	0x2591, 0x2592, 0x2593, 0x2320, 0x25A0, 0x2219, 0x221A, 0x2248,
+	0x2264, 0x2265, 0x00A0, 0x2321, 0x00B0, 0x00B2, 0x00B7, 0x00F7,
+	0x2550, 0x2551, 0x2552, 0x0451, 0x0454, 0x2554, 0x0456, 0x0457,
+	0x2557, 0x2558, 0x2559, 0x255A, 0x255B, 0x0491, 0x255D, 0x255E,
+	0x255F, 0x2560, 0x2561, 0x0401, 0x0404, 0x2563, 0x0406, 0x0407,
+	0x2566, 0x2567, 0x2568, 0x2569, 0x256A, 0x0490, 0x256C, 0x00A9,
+	0x044E, 0x0430, 0x0431, 0x0446, 0x0434, 0x0435, 0x0444, 0x0433,
+	0x0445, 0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E,
+	0x043F, 0x044F, 0x0440, 0x0441, 0x0442, 0x0443, 0x0436, 0x0432,
+	0x044C, 0x044B, 0x0437, 0x0448, 0x044D, 0x0449, 0x0447, 0x044A,
+	0x042E, 0x0410, 0x0411, 0x0426, 0x0414, 0x0415, 0x0424, 0x0413,
+	0x0425, 0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E,
+	0x041F, 0x042F, 0x0420, 0x0421, 0x0422, 0x0423, 0x0416, 0x0412,
+	0x042C, 0x042B, 0x0417, 0x0428, 0x042D, 0x0429, 0x0427, 0x042A,
+}
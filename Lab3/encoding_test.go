@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const fixtureText = "Привіт, світе! Ґанок і їжак.\n"
+
+// fixtures hold fixtureText as Python's codecs encode it.
+var fixtures = map[*Encoding]string{
+	Windows1251: "cff0e8e2b3f22c20f1e2b3f2e52120a5e0edeeea20b320bfe6e0ea2e0a",
+	KOI8U:       "f0d2c9d7a6d42c20d3d7a6d4c52120bdc1cecfcb20a620a7d6c1cb2e0a",
+	UTF16LE:     "1f04400438043204560442042c0020004104320456044204350421002000900430043d043e043a042000560420005704360430043a042e000a00",
+	UTF16BE:     "041f04400438043204560442002c0020044104320456044204350021002004900430043d043e043a002004560020045704360430043a002e000a",
+}
+
+func fixture(t *testing.T, enc *Encoding) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(fixtures[enc])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	utf8Text := []byte(fixtureText)
+	tests := []struct {
+		name   string
+		sample []byte
+		want   *Encoding
+		bom    int
+	}{
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, utf8Text...), UTF8, 3},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, fixture(t, UTF16LE)...), UTF16LE, 2},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, fixture(t, UTF16BE)...), UTF16BE, 2},
+		// A BOM decides even when the rest would say otherwise.
+		{"bom over content", append([]byte{0xFF, 0xFE}, utf8Text...), UTF16LE, 2},
+		{"utf-8", utf8Text, UTF8, 0},
+		{"ascii", []byte("plain text\n"), UTF8, 0},
+		{"empty", nil, UTF8, 0},
+		{"utf-8 cut in a character", utf8Text[:3], UTF8, 0},
+		{"utf-16le", fixture(t, UTF16LE), UTF16LE, 0},
+		{"utf-16be", fixture(t, UTF16BE), UTF16BE, 0},
+		{"ascii utf-16le", []byte("a\x00b\x00c\x00d\x00"), UTF16LE, 0},
+		{"windows-1251", fixture(t, Windows1251), Windows1251, 0},
+		{"koi8-u", fixture(t, KOI8U), KOI8U, 0},
+		// Without Ukrainian letters either way, Windows-1251 is the default.
+		{"ambiguous", []byte{0x80, 0x81, 0x82, 0x83}, Windows1251, 0},
+		{"stray zero bytes", []byte("ab\x00cdef\x00gh"), Windows1251, 0},
+	}
+	for _, tt := range tests {
+		enc, bom := DetectEncoding(tt.sample)
+		if enc != tt.want || bom != tt.bom {
+			t.Errorf("%s: got %s with a %d byte BOM, want %s with %d", tt.name, enc.Name, bom, tt.want.Name, tt.bom)
+		}
+	}
+}
+
+func TestDecodeFixtures(t *testing.T) {
+	for enc := range fixtures {
+		for _, given := range []*Encoding{nil, enc} {
+			p := &Processor{Pipeline: Pipeline{}, Tokenizer: &Tokenizer{Preserve: true}, InputEncoding: given}
+			var out strings.Builder
+			if _, err := p.Process(bytes.NewReader(fixture(t, enc)), &out, 0); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != fixtureText {
+				t.Errorf("%s (given %v): decoded %q", enc.Name, given != nil, out.String())
+			}
+		}
+	}
+}
+
+func TestEncodeFixtures(t *testing.T) {
+	for enc := range fixtures {
+		var out bytes.Buffer
+		w := newEncodingWriter(&out, enc)
+		// Characters split between writes must survive.
+		text := []byte(fixtureText)
+		for i := range text {
+			w.Write(text[i : i+1])
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		want := fixture(t, enc)
+		if enc.order != nil {
+			want = append(enc.order.AppendUint16(nil, 0xFEFF), want...)
+		}
+		if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("%s: encoded %x, want %x", enc.Name, out.Bytes(), want)
+		}
+	}
+}
+
+func TestEncodingEdgeCases(t *testing.T) {
+	encode := func(enc *Encoding, s string) []byte {
+		var out bytes.Buffer
+		w := newEncodingWriter(&out, enc)
+		w.Write([]byte(s))
+		w.Flush()
+		return out.Bytes()
+	}
+	if got := encode(Windows1251, "€ ☺ я"); !bytes.Equal(got, []byte{0x88, ' ', '?', ' ', 0xFF}) {
+		t.Errorf("windows-1251: got %x", got)
+	}
+	if got := encode(KOI8U, "😀"); !bytes.Equal(got, []byte("?")) {
+		t.Errorf("koi8-u: got %x", got)
+	}
+	if got := encode(UTF16LE, "😀"); !bytes.Equal(got, []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x00, 0xDE}) {
+		t.Errorf("utf-16le: got %x", got)
+	}
+	if got := encode(UTF16BE, "😀"); !bytes.Equal(got, []byte{0xFE, 0xFF, 0xD8, 0x3D, 0xDE, 0x00}) {
+		t.Errorf("utf-16be: got %x", got)
+	}
+	// An incomplete character at the end becomes '?'.
+	if got := encode(KOI8U, "я\xd1"); !bytes.Equal(got, []byte{0xD1, '?'}) {
+		t.Errorf("koi8-u incomplete: got %x", got)
+	}
+
+	decode := func(enc *Encoding, b []byte) string {
+		p := &Processor{Pipeline: Pipeline{}, Tokenizer: &Tokenizer{Preserve: true}, InputEncoding: enc}
+		var out strings.Builder
+		if _, err := p.Process(bytes.NewReader(b), &out, 0); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	if got := decode(UTF16LE, []byte{0x3D, 0xD8, 0x00, 0xDE, 'a', 0}); got != "😀a" {
+		t.Errorf("utf-16le surrogates: got %q", got)
+	}
+	if got := decode(UTF16LE, []byte{0x3D, 0xD8, 'a', 0}); got != "�a" {
+		t.Errorf("utf-16le lone surrogate: got %q", got)
+	}
+	if got := decode(UTF16BE, []byte{0, 'a', 0}); got != "a�" {
+		t.Errorf("utf-16be odd byte: got %q", got)
+	}
+	if got := decode(Windows1251, []byte{0x98}); got != "�" {
+		t.Errorf("windows-1251 undefined byte: got %q", got)
+	}
+}
+
+func TestLookupEncoding(t *testing.T) {
+	for name, want := range map[string]*Encoding{"UTF-8": UTF8, "cp1251": Windows1251, "KOI8U": KOI8U, "utf-16": UTF16LE, "utf-16be": UTF16BE} {
+		if got, err := LookupEncoding(name); got != want || err != nil {
+			t.Errorf("LookupEncoding(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := LookupEncoding("latin1"); err == nil || !strings.Contains(err.Error(), "koi8-u") {
+		t.Errorf("LookupEncoding(\"latin1\"): got %v", err)
+	}
+}
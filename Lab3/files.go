@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdioPath stands for stdin as an input and stdout as an output.
+const stdioPath = "-"
+
+// ExistingPolicy decides what happens when an output file already exists.
+type ExistingPolicy int
+
+const (
+	Overwrite ExistingPolicy = iota
+	Skip
+	Suffix
+)
+
+// fileJob is one input file and the output it is written to.
+type fileJob struct {
+	Input  string
+	Output string
+}
+
+func ParseExistingPolicy(name string) (ExistingPolicy, error) {
+	switch name {
+	case "overwrite":
+		return Overwrite, nil
+	case "skip":
+		return Skip, nil
+	case "suffix":
+		return Suffix, nil
+	default:
+		return 0, fmt.Errorf("невідома політика %q (overwrite, skip або suffix)", name)
+	}
+}
+
+// planJobs expands the inputs (files, directories, glob patterns or "-")
+// into jobs. A single plain file is written to output, or into output when
+// it is an existing directory. Several files are written into the output
+// directory, mirroring their paths relative to the directory or glob they
+// came from. Directories are walked recursively only when recursive is set;
+// pattern filters the names of files found in directories.
+func planJobs(inputs []string, output string, recursive bool, pattern string) ([]fileJob, error) {
+	if len(inputs) == 1 && inputs[0] == stdioPath {
+		if isDir(output) {
+			return nil, fmt.Errorf("%s є каталогом, а вхід читається зі stdin", output)
+		}
+		return []fileJob{{Input: stdioPath, Output: output}}, nil
+	}
+
+	type entry struct{ path, rel string }
+	var entries []entry
+	single := len(inputs) == 1
+	absOutput, _ := filepath.Abs(output)
+
+	for _, input := range inputs {
+		if input == stdioPath {
+			return nil, errors.New("stdin (-) не можна поєднувати з іншими вхідними файлами")
+		}
+
+		matches := []string{input}
+		base := ""
+		if strings.ContainsAny(input, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(input); err != nil {
+				return nil, fmt.Errorf("неправильний шаблон %q: %w", input, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("шаблон %q не знайшов жодного файлу", input)
+			}
+			base = globBase(input)
+			single = false
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				root := base
+				if root == "" {
+					root = filepath.Dir(match)
+				}
+				rel, _ := filepath.Rel(root, match)
+				entries = append(entries, entry{match, rel})
+				continue
+			}
+
+			single = false
+			root := match
+			if base != "" {
+				root = base
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					abs, _ := filepath.Abs(path)
+					if path != match && (!recursive || abs == absOutput) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if ok, _ := filepath.Match(pattern, d.Name()); !ok || !d.Type().IsRegular() {
+					return nil
+				}
+				rel, _ := filepath.Rel(root, path)
+				entries = append(entries, entry{path, rel})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if single && len(entries) == 1 {
+		out := output
+		if isDir(output) {
+			out = filepath.Join(output, filepath.Base(entries[0].path))
+		}
+		return []fileJob{{Input: entries[0].path, Output: out}}, nil
+	}
+
+	if output != stdioPath {
+		if info, err := os.Stat(output); err == nil && !info.IsDir() {
+			return nil, fmt.Errorf("для кількох вхідних файлів %s має бути каталогом", output)
+		}
+	}
+	jobs := make([]fileJob, 0, len(entries))
+	for _, e := range entries {
+		out := output
+		if output != stdioPath {
+			out = filepath.Join(output, e.rel)
+		}
+		jobs = append(jobs, fileJob{Input: e.path, Output: out})
+	}
+	return jobs, nil
+}
+
+// intoDirectory reports whether the jobs treat output as a directory to
+// write their files into, rather than as the output file itself.
+func intoDirectory(jobs []fileJob, output string) bool {
+	return len(jobs) > 1 || (len(jobs) == 1 && jobs[0].Output != output)
+}
+
+// globBase returns the directory part of a pattern before the first
+// component containing a wildcard.
+func globBase(pattern string) string {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			base := filepath.FromSlash(strings.Join(parts[:i], "/"))
+			if base == "" {
+				if strings.HasPrefix(pattern, "/") {
+					return string(filepath.Separator)
+				}
+				return "."
+			}
+			return base
+		}
+	}
+	return filepath.Dir(pattern)
+}
+
+// resolveOutput applies the policy to an output path. It returns the path
+// to write to, or "" when the job is skipped.
+func resolveOutput(path string, policy ExistingPolicy) (string, error) {
+	if path == stdioPath {
+		return path, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return path, nil
+	}
+
+	switch policy {
+	case Skip:
+		return "", nil
+	case Suffix:
+		ext := filepath.Ext(path)
+		stem := strings.TrimSuffix(path, ext)
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%s.%d%s", stem, i, ext)
+			if _, err := os.Stat(candidate); errors.Is(err, fs.ErrNotExist) {
+				return candidate, nil
+			}
+		}
+	default:
+		return path, nil
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates the files under dir, each holding its own name.
+func writeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlanJobs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "in/a.txt", "in/b.md", "in/sub/c.txt", "in/sub/deep/d.txt", "out/old.txt", "file.txt")
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	job := func(input, output string) fileJob {
+		return fileJob{Input: filepath.Join(dir, input), Output: filepath.Join(dir, output)}
+	}
+
+	tests := []struct {
+		name      string
+		inputs    []string
+		output    string
+		recursive bool
+		pattern   string
+		want      []fileJob
+	}{
+		{"single file", []string{filepath.Join(dir, "file.txt")}, filepath.Join(dir, "result.txt"), false, "*",
+			[]fileJob{job("file.txt", "result.txt")}},
+		{"single file into a directory", []string{filepath.Join(dir, "file.txt")}, out, false, "*",
+			[]fileJob{job("file.txt", "out/file.txt")}},
+		{"directory", []string{in}, out, false, "*",
+			[]fileJob{job("in/a.txt", "out/a.txt"), job("in/b.md", "out/b.md")}},
+		{"recursive", []string{in}, out, true, "*",
+			[]fileJob{job("in/a.txt", "out/a.txt"), job("in/b.md", "out/b.md"), job("in/sub/c.txt", "out/sub/c.txt"), job("in/sub/deep/d.txt", "out/sub/deep/d.txt")}},
+		{"recursive with pattern", []string{in}, out, true, "*.txt",
+			[]fileJob{job("in/a.txt", "out/a.txt"), job("in/sub/c.txt", "out/sub/c.txt"), job("in/sub/deep/d.txt", "out/sub/deep/d.txt")}},
+		// The output directory inside the input is not read back.
+		{"output inside input", []string{dir}, out, true, "old.txt", nil},
+		{"glob", []string{filepath.Join(in, "*", "*.txt")}, out, false, "*",
+			[]fileJob{job("in/sub/c.txt", "out/sub/c.txt")}},
+		{"several files", []string{filepath.Join(dir, "file.txt"), filepath.Join(in, "a.txt")}, out, false, "*",
+			[]fileJob{job("file.txt", "out/file.txt"), job("in/a.txt", "out/a.txt")}},
+		{"stdin", []string{stdioPath}, stdioPath, false, "*", []fileJob{{Input: stdioPath, Output: stdioPath}}},
+		{"directory to stdout", []string{filepath.Join(in, "sub")}, stdioPath, false, "*",
+			[]fileJob{{Input: filepath.Join(in, "sub", "c.txt"), Output: stdioPath}}},
+	}
+	for _, tt := range tests {
+		got, err := planJobs(tt.inputs, tt.output, tt.recursive, tt.pattern)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	errorCases := []struct {
+		name   string
+		inputs []string
+		output string
+	}{
+		{"stdin into a directory", []string{stdioPath}, out},
+		{"stdin with files", []string{stdioPath, in}, out},
+		{"several files into a file", []string{in}, filepath.Join(dir, "file.txt")},
+		{"glob without matches", []string{filepath.Join(dir, "*.none")}, out},
+		{"missing input", []string{filepath.Join(dir, "missing.txt")}, out},
+	}
+	for _, tt := range errorCases {
+		if _, err := planJobs(tt.inputs, tt.output, false, "*"); err == nil {
+			t.Errorf("%s: planned without an error", tt.name)
+		}
+	}
+}
+
+func TestIntoDirectory(t *testing.T) {
+	tests := []struct {
+		jobs []fileJob
+		want bool
+	}{
+		{nil, false},
+		{[]fileJob{{Input: "a.txt", Output: "output.txt"}}, false},
+		{[]fileJob{{Input: "a.txt", Output: "output.txt/a.txt"}}, true},
+		{[]fileJob{{Input: "a.txt", Output: "output.txt/a.txt"}, {Input: "b.txt", Output: "output.txt/b.txt"}}, true},
+	}
+	for _, tt := range tests {
+		if got := intoDirectory(tt.jobs, "output.txt"); got != tt.want {
+			t.Errorf("intoDirectory(%v) = %v, want %v", tt.jobs, got, tt.want)
+		}
+	}
+}
+
+func TestResolveOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "taken.txt", "taken.1.txt")
+	taken, free := filepath.Join(dir, "taken.txt"), filepath.Join(dir, "free.txt")
+	tests := []struct {
+		path   string
+		policy ExistingPolicy
+		want   string
+	}{
+		{free, Overwrite, free},
+		{free, Skip, free},
+		{free, Suffix, free},
+		{taken, Overwrite, taken},
+		{taken, Skip, ""},
+		{taken, Suffix, filepath.Join(dir, "taken.2.txt")},
+		{stdioPath, Skip, stdioPath},
+	}
+	for _, tt := range tests {
+		got, err := resolveOutput(tt.path, tt.policy)
+		if err != nil || got != tt.want {
+			t.Errorf("resolveOutput(%q, %v) = %q, %v; want %q", tt.path, tt.policy, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// graphemes splits s into extended grapheme clusters, the user-perceived
+// characters of Unicode Standard Annex #29: a letter with its combining
+// marks, an emoji with its modifiers and ZWJ-joined parts, a flag made of
+// two regional indicators, a Hangul syllable or CR LF all stay together.
+//
+// The rules are a simplified form of the annex, without the Unicode
+// property tables:
+//   - Extend (GB9) is the Mn and Me categories, ZWNJ, variation selectors,
+//     skin-tone modifiers and tag characters, not Other_Grapheme_Extend;
+//   - SpacingMark (GB9a) is the Mc category, without its exceptions;
+//   - Control (GB4, GB5) is the Cc category and the line and paragraph
+//     separators; format characters such as U+00AD do not break;
+//   - Extended_Pictographic (GB11) is approximated by the emoji blocks;
+//   - Prepend (GB9b) and the Indic conjunct rule (GB9c) are not applied.
+func graphemes(s string) []string {
+	var clusters []string
+	start := 0
+	var prev rune = -1
+	emoji := false    // the cluster so far is an Extended_Pictographic followed by Extend characters
+	afterZWJ := false // ... and then a zero-width joiner
+	regional := 0     // regional indicators in a row
+	for i, r := range s {
+		if prev >= 0 && graphemeBreak(prev, r, afterZWJ, regional) {
+			clusters = append(clusters, s[start:i])
+			start = i
+			emoji, regional = false, 0
+		}
+
+		switch {
+		case isExtendedPictographic(r):
+			emoji = true
+		case r == zeroWidthJoiner:
+			afterZWJ = emoji
+			prev = r
+			continue
+		case !isGraphemeExtend(r):
+			emoji = false
+		}
+		afterZWJ = false
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// graphemeBreak reports whether there is a cluster boundary between prev
+// and next.
+func graphemeBreak(prev, next rune, afterZWJ bool, regional int) bool {
+	switch {
+	case prev == '\r' && next == '\n':
+		return false
+	case isGraphemeControl(prev) || isGraphemeControl(next):
+		return true
+	case hangulJoins(prev, next):
+		return false
+	case isGraphemeExtend(next) || next == zeroWidthJoiner || unicode.Is(unicode.Mc, next):
+		return false
+	case afterZWJ && isExtendedPictographic(next):
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(next):
+		return regional%2 == 0
+	}
+	return true
+}
+
+func isGraphemeControl(r rune) bool {
+	return r == '\r' || r == '\n' || (unicode.IsControl(r) && r != zeroWidthJoiner) ||
+		r == '\u2028' || r == '\u2029'
+}
+
+// isGraphemeExtend covers combining marks, variation selectors, emoji
+// skin-tone modifiers and tag characters.
+func isGraphemeExtend(r rune) bool {
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) ||
+		r == '\u200c' ||
+		(r >= 0xFE00 && r <= 0xFE0F) || (r >= 0xE0100 && r <= 0xE01EF) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isExtendedPictographic approximates the Extended_Pictographic property
+// with the blocks that hold emoji.
+func isExtendedPictographic(r rune) bool {
+	switch r {
+	case 0x00A9, 0x00AE, 0x203C, 0x2049, 0x2122, 0x2139, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+	return (r >= 0x2194 && r <= 0x21AA) || (r >= 0x2300 && r <= 0x23FF) ||
+		(r >= 0x25A0 && r <= 0x27BF) || (r >= 0x2B00 && r <= 0x2BFF) ||
+		(r >= 0x1F000 && r <= 0x1FAFF && !isRegionalIndicator(r) && !(r >= 0x1F3FB && r <= 0x1F3FF))
+}
+
+// hangulJoins reports whether two Hangul jamo or syllables belong to one
+// syllable block.
+func hangulJoins(prev, next rune) bool {
+	p, n := hangulType(prev), hangulType(next)
+	switch p {
+	case 'L':
+		return n == 'L' || n == 'V' || n == 'v' || n == 't'
+	case 'V', 'v':
+		return n == 'V' || n == 'T'
+	case 'T', 't':
+		return n == 'T'
+	}
+	return false
+}
+
+// hangulType classifies a rune as a leading (L), vowel (V) or trailing (T)
+// jamo, an LV syllable (v), an LVT syllable (t) or none (0).
+func hangulType(r rune) byte {
+	switch {
+	case (r >= 0x1100 && r <= 0x115F) || (r >= 0xA960 && r <= 0xA97C):
+		return 'L'
+	case (r >= 0x1160 && r <= 0x11A7) || (r >= 0xD7B0 && r <= 0xD7C6):
+		return 'V'
+	case (r >= 0x11A8 && r <= 0x11FF) || (r >= 0xD7CB && r <= 0xD7FB):
+		return 'T'
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return 'v'
+		}
+		return 't'
+	}
+	return 0
+}
+
+// firstRune returns the base character of a cluster.
+func firstRune(cluster string) rune {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	return r
+}
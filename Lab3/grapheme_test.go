@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"ascii", "abc", []string{"a", "b", "c"}},
+		{"cyrillic", "їжак", []string{"ї", "ж", "а", "к"}},
+		{"combining acute", "e\u0301a", []string{"e\u0301", "a"}},
+		{"combining breve", "\u0438\u0306\u0442\u0438", []string{"\u0438\u0306", "\u0442", "\u0438"}},
+		{"several marks", "a\u0301\u0323\u0308b", []string{"a\u0301\u0323\u0308", "b"}},
+		{"spacing mark", "\u0915\u093F", []string{"\u0915\u093F"}},
+		{"leading mark", "\u0301a", []string{"\u0301", "a"}},
+		{"cr lf", "a\r\nb", []string{"a", "\r\n", "b"}},
+		{"cr cr", "\r\r", []string{"\r", "\r"}},
+		{"control", "a\t\u0301", []string{"a", "\t", "\u0301"}},
+		{"zwj family", "\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466!", []string{"\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466", "!"}},
+		{"zwj with skin tone", "\U0001F469\U0001F3FD\u200D\U0001F4BB", []string{"\U0001F469\U0001F3FD\u200D\U0001F4BB"}},
+		{"skin tone", "\U0001F44D\U0001F3FF\U0001F44D", []string{"\U0001F44D\U0001F3FF", "\U0001F44D"}},
+		{"variation selector", "\u2764\uFE0F\u2764", []string{"\u2764\uFE0F", "\u2764"}},
+		{"keycap", "1\uFE0F\u20E3", []string{"1\uFE0F\u20E3"}},
+		{"zwj after a letter", "a\u200D\U0001F44D", []string{"a\u200D", "\U0001F44D"}},
+		{"trailing zwj", "\U0001F44D\u200D", []string{"\U0001F44D\u200D"}},
+		{"flag tags", "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", []string{"🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"}},
+		{"flag", "🇺🇦", []string{"🇺🇦"}},
+		{"flag pairs", "🇺🇦🇵🇱", []string{"🇺🇦", "🇵🇱"}},
+		{"odd regional indicators", "🇺🇦🇵", []string{"🇺🇦", "🇵"}},
+		{"flags split by a letter", "🇺a🇦🇵", []string{"🇺", "a", "🇦🇵"}},
+		{"hangul syllables", "한글", []string{"한", "글"}},
+		{"hangul jamo L V T", "\u1100\u1161\u11A8\u1100", []string{"\u1100\u1161\u11A8", "\u1100"}},
+		{"hangul LV T", "\uAC00\u11A8", []string{"\uAC00\u11A8"}},
+		{"hangul LVT T", "\uAC01\u11A8", []string{"\uAC01\u11A8"}},
+		{"hangul LVT V", "\uAC01\u1161", []string{"\uAC01", "\u1161"}},
+		{"hangul L L V", "\u1100\u1100\u1161", []string{"\u1100\u1100\u1161"}},
+	}
+	for _, tt := range tests {
+		if got := graphemes(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: graphemes(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,69 +1,494 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"math/rand"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+const defaultPipeline = "double,shuffle"
+
 func main() {
-	rand.Seed(time.Now().UnixNano())
-	inputFileName := "input.txt"
-	outputFileName := "output.txt"
+	// "decode" restores text written with a keyed, reversible pipeline;
+	// "encrypt" and "decrypt" apply the ciphers given by -cipher; "crack"
+	// finds the shift of a Caesar cipher and decrypts with it; "puzzle"
+	// writes a sheet of scrambled words and its answer key.
+	command := ""
+	args := os.Args[1:]
+	if len(os.Args) > 1 && commands[os.Args[1]] {
+		command, args = os.Args[1], os.Args[2:]
+	}
+	decode := command == "decode"
+
+	inputPath := flag.String("in", "input.txt", "вхідний файл, каталог або шаблон; - означає stdin")
+	outputPath := flag.String("out", "output.txt", "вихідний файл або каталог; - означає stdout")
+	recursive := flag.Bool("r", false, "обходити вхідні каталоги рекурсивно")
+	filePattern := flag.String("pattern", "*", "шаблон імен файлів у вхідних каталогах")
+	existing := flag.String("existing", "overwrite", "що робити з наявними вихідними файлами: overwrite, skip або suffix")
+	configPath := flag.String("config", "", "JSON-файл конфігурації з кроками перетворення")
+	pipelineSpec := flag.String("pipeline", defaultPipeline, "кроки перетворення через кому, з параметрами через двокрапку (caesar:shift=3)")
+	listTransforms := flag.Bool("list", false, "вивести доступні перетворення")
+	maxLineLength := flag.Int("max-line", defaultMaxLineLength, "найбільша довжина рядка в байтах")
+	showProgress := flag.Bool("progress", false, "показувати хід обробки у stderr")
+	seed := flag.String("seed", "", "зерно для відтворюваного перемішування")
+	key := flag.String("key", "", "секретний ключ оборотного перемішування; вмикає й escape для double")
+	split := flag.String("split", defaultSplit, "символи, що розділяють слова")
+	joiner := flag.String("join", defaultJoiner, "рядок, яким з'єднуються слова у виході")
+	workers := flag.Int("workers", 1, "кількість файлів або частин файлу, що обробляються одночасно")
+	chunkLines := flag.Int("chunk", defaultChunkLines, "кількість рядків в одній частині файлу при паралельній обробці")
+	inputEncoding := flag.String("input-encoding", "auto", "кодування вхідних файлів: auto, utf-8, utf-16le, utf-16be, windows-1251 або koi8-u")
+	outputEncoding := flag.String("output-encoding", "utf-8", "кодування вихідних файлів; input означає кодування вхідного файлу")
+	reportMode := flag.String("report", "summary", "звіт про обробку: quiet, summary, verbose (з різницею рядків) або json")
+	dryRun := flag.Bool("dry-run", false, "лише показати, що зміниться, нічого не записуючи")
+	diffFormat := flag.String("diff", "unified", "формат різниці для -dry-run: unified або side-by-side")
+	cipher := flag.String("cipher", "", "шифри для encrypt і decrypt, як у -pipeline (vigenere:key=ключ)")
+	language := flag.String("lang", "uk", "мова тексту для crack: en або uk")
+	dictionaryPath := flag.String("dict", "", "словник для puzzle, по слову в рядку")
+	puzzleCount := flag.Int("count", 20, "кількість слів для puzzle")
+	minLength := flag.Int("min-length", 4, "найменша довжина слова для puzzle")
+	answersPath := flag.String("answers", "", "файл відповідей для puzzle; типово поруч із -out")
+	watch := flag.Bool("watch", false, "стежити за вхідними файлами й повторно обробляти змінені")
+	pollInterval := flag.Duration("poll", 500*time.Millisecond, "як часто перевіряти вхідні файли в режимі -watch")
+	debounce := flag.Duration("debounce", 300*time.Millisecond, "скільки файл має не змінюватися перед повторною обробкою")
+	documentFormat := flag.String("format", "auto", "формат вхідних файлів: auto (за розширенням), text, markdown, html або json")
+	preserve := flag.Bool("preserve", false, "зберігати розділові знаки й пробіли, слова визначати за Unicode")
+	flag.CommandLine.Parse(args)
+
+	// Ciphers keep the layout of the text unless asked otherwise.
+	if command == "encrypt" || command == "decrypt" || command == "crack" || command == "puzzle" {
+		if !flagWasSet("preserve") {
+			*preserve = true
+		}
+	}
+
+	if decode {
+		if !flagWasSet("in") {
+			*inputPath = "output.txt"
+		}
+		if !flagWasSet("out") {
+			*outputPath = "decoded.txt"
+		}
+	}
+
+	if *listTransforms {
+		printTransforms()
+		return
+	}
+
+	defaults := map[string]Params{"shuffle": {}, "double": {}}
+	if *seed != "" {
+		defaults["shuffle"]["seed"] = *seed
+	}
+	if *key != "" {
+		defaults["shuffle"]["key"] = *key
+		defaults["double"]["escape"] = "true"
+	}
+	var pipeline Pipeline
+	var err error
+	switch command {
+	case "encrypt", "decrypt":
+		pipeline, err = loadCipher(*cipher, command == "decrypt")
+	case "crack", "puzzle":
+		// The pipeline is known once the input has been analysed.
+	default:
+		pipeline, err = loadPipeline(*configPath, *pipelineSpec, flagWasSet("pipeline"), defaults)
+		if err == nil && decode {
+			pipeline, err = pipeline.Inverse()
+		}
+	}
+	if err != nil {
+		fmt.Println("Помилка налаштування перетворень:", err)
+		os.Exit(2)
+	}
+
+	policy, err := ParseExistingPolicy(*existing)
+	if err != nil {
+		fmt.Println("Помилка:", err)
+		os.Exit(2)
+	}
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{*inputPath}
+	}
+	planOutput := *outputPath
+	if command == "puzzle" {
+		// The words of all inputs make one sheet.
+		planOutput = stdioPath
+	}
+	plan := func() ([]fileJob, error) {
+		return planJobs(inputs, planOutput, *recursive, *filePattern)
+	}
+	jobs, err := plan()
+	if err != nil {
+		fmt.Println("Помилка вибору файлів:", err)
+		os.Exit(2)
+	}
+	if !flagWasSet("out") && planOutput != stdioPath && intoDirectory(jobs, planOutput) {
+		// The default output is a file name; it must not become a directory.
+		fmt.Println("Помилка вибору файлів: для кількох вхідних файлів або каталогу вкажіть вихідний каталог через -out")
+		os.Exit(2)
+	}
 
-	data, err := ioutil.ReadFile(inputFileName)
+	// Messages go to stderr when the result itself is written to stdout.
+	messages := os.Stdout
+	if *outputPath == stdioPath && !*dryRun {
+		messages = os.Stderr
+	}
+	tokenizer := &Tokenizer{Split: *split, Joiner: *joiner, Preserve: *preserve}
+	if *preserve && !flagWasSet("join") {
+		tokenizer.Joiner = ""
+	}
+	if *key != "" {
+		// Keep the escapes of a reversible double step inside words.
+		tokenizer.WordChars = `+\`
+	}
+	if *key != "" && !*preserve {
+		// Words may contain the joiner; escape it so decoding can tell them apart.
+		tokenizer.EscapeJoiner, tokenizer.UnescapeSplit = !decode, decode
+	}
+	if decode && !*preserve {
+		// Decoding reads words joined by the encoder and separates them with spaces.
+		tokenizer.Split, tokenizer.Joiner = tokenizer.Joiner, " "
+	}
+	report, err := ParseReportMode(*reportMode)
+	if err != nil {
+		fmt.Println("Помилка:", err)
+		os.Exit(2)
+	}
+	format, err := ParseDiffFormat(*diffFormat)
 	if err != nil {
-		fmt.Println("Помилка читання файлу:", err)
+		fmt.Println("Помилка:", err)
+		os.Exit(2)
+	}
+	if *documentFormat, err = ParseFormat(*documentFormat); err != nil {
+		fmt.Println("Помилка:", err)
+		os.Exit(2)
+	}
+	processor := &Processor{Pipeline: pipeline, MaxLineLength: *maxLineLength, Tokenizer: tokenizer, Diffs: report >= ReportVerbose, Format: *documentFormat}
+	if *showProgress {
+		processor.Progress = os.Stderr
+	}
+	if *inputEncoding != "auto" {
+		if processor.InputEncoding, err = LookupEncoding(*inputEncoding); err != nil {
+			fmt.Println("Помилка:", err)
+			os.Exit(2)
+		}
+	}
+	if *outputEncoding == "input" {
+		processor.KeepEncoding = true
+	} else if processor.OutputEncoding, err = LookupEncoding(*outputEncoding); err != nil {
+		fmt.Println("Помилка:", err)
+		os.Exit(2)
+	}
+
+	if command == "puzzle" {
+		sheetPath := "puzzle.txt"
+		if flagWasSet("out") {
+			sheetPath = *outputPath
+		}
+		if *answersPath == "" {
+			ext := filepath.Ext(sheetPath)
+			*answersPath = strings.TrimSuffix(sheetPath, ext) + ".answers" + ext
+		}
+		rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+		if *seed != "" {
+			n, err := strconv.ParseUint(*seed, 10, 64)
+			if err != nil {
+				fmt.Println("Помилка: зерно має бути невід'ємним цілим числом")
+				os.Exit(2)
+			}
+			rng = rand.New(rand.NewPCG(n, 0))
+		}
+		if err := runPuzzle(jobs, *dictionaryPath, tokenizer, *puzzleCount, *minLength, rng, sheetPath, *answersPath); err != nil {
+			fmt.Println("Помилка:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Завдання записано у %s, відповіді — у %s\n", sheetPath, *answersPath)
 		return
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var outputLines []string
+	if command == "crack" {
+		shift, err := crackJobs(jobs, &Processor{MaxLineLength: *maxLineLength, Tokenizer: tokenizer, Format: *documentFormat}, *language, messages)
+		if err != nil {
+			fmt.Fprintln(messages, "Помилка зламу:", err)
+			os.Exit(1)
+		}
+		processor.Pipeline = Pipeline{caesarTransform{shift: -shift}}
+	}
+
+	// runJobs processes the jobs and reports on them; it returns the number
+	// of files that failed.
+	runJobs := func(jobs []fileJob, policy ExistingPolicy) int {
+		// Several files are processed side by side; a single file is split
+		// into chunks of lines instead. Outputs to stdout stay sequential.
+		fileWorkers := max(*workers, 1)
+		processor.Workers, processor.ChunkLines = 1, *chunkLines
+		if len(jobs) == 1 {
+			processor.Workers = *workers
+		}
+		if *outputPath == stdioPath || *dryRun {
+			// A dry run prints each diff as its file is read.
+			fileWorkers = 1
+		}
+		diff := diffWriter{w: messages, format: format, color: useColor(messages)}
 
-	for _, line := range lines {
-		words := strings.FieldsFunc(line, func(r rune) bool {
-			return r == ' ' || r == ','
-		})
-		var newWords []string
-		for _, word := range words {
-			newWord := replaceDoubleLetters(word)
-			fmt.Println("Слово з заміненими подвоєними літерами:", newWord)
-			shuffledWord := shuffleString(newWord)
-			fmt.Println("Слово з перемішаними літерами:", shuffledWord)
-			newWords = append(newWords, shuffledWord)
+		// Outputs are resolved up front so that the suffix policy never hands
+		// the same name to two workers.
+		results := make([]error, len(jobs))
+		outputs := make([]string, len(jobs))
+		stats := make([]*Stats, len(jobs))
+		var wg sync.WaitGroup
+		pool := NewWorkerPool(fileWorkers, &wg)
+		pool.Run()
+		for i, job := range jobs {
+			outputs[i], results[i] = resolveOutput(job.Output, policy)
+			if results[i] != nil || outputs[i] == "" {
+				continue
+			}
+			wg.Add(1)
+			pool.Jobs <- Job{Id: i + 1, Description: job.Input, Run: func() {
+				if *dryRun {
+					fd := diff.File(job.Input, outputs[i])
+					preview := *processor
+					preview.Diff = fd.Line
+					stats[i], results[i] = previewFile(job.Input, &preview)
+					fd.Finish(stats[i] != nil && stats[i].Unterminated)
+				} else {
+					stats[i], results[i] = processFile(job.Input, outputs[i], processor)
+				}
+			}}
 		}
-		outputLines = append(outputLines, strings.Join(newWords, "-"))
+		wg.Wait()
+		close(pool.Jobs)
+
+		processed, skipped := 0, 0
+		var failures []error
+		var done []*Stats
+		failed := map[string]error{}
+		for i, job := range jobs {
+			switch {
+			case results[i] != nil:
+				failures = append(failures, fmt.Errorf("%s: %w", job.Input, results[i]))
+				failed[job.Input] = results[i]
+				done = append(done, &Stats{Input: job.Input, Output: outputs[i]})
+			case outputs[i] == "":
+				if report != ReportQuiet && report != ReportJSON {
+					fmt.Fprintln(messages, "Пропущено, вихідний файл уже існує:", job.Output)
+				}
+				skipped++
+			default:
+				stats[i].Input, stats[i].Output = job.Input, outputs[i]
+				done = append(done, stats[i])
+				processed++
+			}
+		}
+
+		switch report {
+		case ReportJSON:
+			if err := WriteJSONReport(messages, done, failed); err != nil {
+				fmt.Fprintln(os.Stderr, "Помилка запису звіту:", err)
+			}
+		case ReportSummary, ReportVerbose:
+			for _, s := range done {
+				if _, ok := failed[s.Input]; !ok {
+					s.WriteText(messages, report == ReportVerbose && !*dryRun)
+				}
+			}
+			if *dryRun {
+				fmt.Fprintln(messages, "Пробний запуск: жодного файлу не записано.")
+			} else if len(jobs) == 1 && processed == 1 && outputs[0] != stdioPath {
+				fmt.Fprintln(messages, "Обробку завершено. Перевірте", outputs[0])
+			}
+			if len(jobs) > 1 && !*dryRun {
+				fmt.Fprintf(messages, "Обробку завершено. Оброблено файлів: %d, пропущено: %d, з помилками: %d\n", processed, skipped, len(failures))
+			}
+		}
+		if report != ReportJSON {
+			for _, err := range failures {
+				fmt.Fprintln(os.Stderr, "Помилка обробки файлу", err)
+			}
+		}
+		return len(failures)
 	}
 
-	outputData := strings.Join(outputLines, "\n")
+	failures := runJobs(jobs, policy)
+	if *watch {
+		if err := watchJobs(jobs, plan, runJobs, *pollInterval, *debounce, messages); err != nil {
+			fmt.Fprintln(messages, "Помилка:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
 
-	err = ioutil.WriteFile(outputFileName, []byte(outputData), 0644)
+var commands = map[string]bool{"decode": true, "encrypt": true, "decrypt": true, "crack": true, "puzzle": true}
+
+// loadCipher builds the pipeline of the encrypt and decrypt commands; it
+// accepts only ciphers, which can all be undone.
+func loadCipher(spec string, decrypt bool) (Pipeline, error) {
+	steps, err := ParsePipeline(spec)
 	if err != nil {
-		fmt.Println("Помилка запису файлу:", err)
-		return
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("задайте шифр прапорцем -cipher (caesar, vigenere, atbash або railfence)")
 	}
+	for _, step := range steps {
+		if !cipherNames[step.Name] {
+			return nil, fmt.Errorf("%q не є шифром (caesar, vigenere, atbash або railfence)", step.Name)
+		}
+	}
+	pipeline, err := BuildPipeline(steps)
+	if err != nil || !decrypt {
+		return pipeline, err
+	}
+	return pipeline.Inverse()
+}
 
-	fmt.Println("Обробку завершено. Перевірте", outputFileName)
+// crackJobs counts the letters of all inputs, reports the likeliest Caesar
+// shifts and returns the best one.
+func crackJobs(jobs []fileJob, counter *Processor, language string, out io.Writer) (int, error) {
+	total := newStats()
+	for _, job := range jobs {
+		if job.Input == stdioPath {
+			return 0, fmt.Errorf("для зламу вхідний текст читається двічі, тож stdin не підтримується")
+		}
+		stats, err := previewFile(job.Input, counter)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", job.Input, err)
+		}
+		total.merge(stats)
+	}
+	guesses, err := CrackCaesar(total.letters, language)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(out, "Найімовірніший зсув: %d (χ² = %.1f)\n", guesses[0].Shift, guesses[0].ChiSquared)
+	for _, g := range guesses[1:min(4, len(guesses))] {
+		fmt.Fprintf(out, "  також можливий зсув %d (χ² = %.1f)\n", g.Shift, g.ChiSquared)
+	}
+	return guesses[0].Shift, nil
+}
+
+// runPuzzle builds a puzzle sheet from the words of all inputs and writes
+// it together with its answer key.
+func runPuzzle(jobs []fileJob, dictionaryPath string, tokenizer *Tokenizer, count, minLength int, rng *rand.Rand, sheetPath, answersPath string) error {
+	var words []string
+	for _, job := range jobs {
+		fileWords, err := collectWords(job.Input, tokenizer)
+		if err != nil {
+			return fmt.Errorf("%s: %w", job.Input, err)
+		}
+		words = append(words, fileWords...)
+	}
+
+	// The words of the text count as dictionary words too, so a scramble
+	// never spells another word of the same text.
+	dictionary := map[string]bool{}
+	for _, word := range words {
+		dictionary[strings.ToLower(word)] = true
+	}
+	if dictionaryPath != "" {
+		dictWords, err := collectWords(dictionaryPath, tokenizer)
+		if err != nil {
+			return fmt.Errorf("словник %s: %w", dictionaryPath, err)
+		}
+		for _, word := range dictWords {
+			dictionary[strings.ToLower(word)] = true
+		}
+	}
+
+	set := BuildPuzzles(words, dictionary, count, minLength, rng)
+	if len(set.Ambiguous) > 0 {
+		fmt.Println("Пропущено слова з кількома можливими відповідями:", strings.Join(set.Ambiguous, ", "))
+	}
+	var sheet, answers strings.Builder
+	set.WriteSheet(&sheet)
+	set.WriteAnswers(&answers)
+	if err := os.WriteFile(sheetPath, []byte(sheet.String()), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(answersPath, []byte(answers.String()), 0644)
+}
+
+// loadPipeline builds the pipeline from the config file, if any; a
+// -pipeline flag given explicitly takes precedence over the file. defaults
+// fills parameters the steps do not set themselves.
+func loadPipeline(configPath, spec string, specSet bool, defaults map[string]Params) (Pipeline, error) {
+	var steps []StepConfig
+	if configPath != "" {
+		config, err := LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		steps = config.Pipeline
+	}
+	if configPath == "" || specSet {
+		var err error
+		if steps, err = ParsePipeline(spec); err != nil {
+			return nil, err
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("не задано жодного кроку перетворення")
+	}
+	for i, step := range steps {
+		for key, value := range defaults[step.Name] {
+			if _, ok := step.Params[key]; ok {
+				continue
+			}
+			if step.Params == nil {
+				steps[i].Params = Params{}
+			}
+			steps[i].Params[key] = value
+		}
+	}
+	return BuildPipeline(steps)
+}
+
+func flagWasSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func printTransforms() {
+	fmt.Println("Доступні перетворення:")
+	for _, name := range transformNames() {
+		fmt.Printf("  %-10s %s\n", name, transformFactories[name].description)
+	}
 }
 
 func replaceDoubleLetters(word string) string {
-	runes := []rune(word)
-	for i := 0; i < len(runes)-1; i++ {
-		if runes[i] == runes[i+1] {
-			runes[i] = '+'
-			runes = append(runes[:i+1], runes[i+2:]...)
+	letters := graphemes(word)
+	for i := 0; i < len(letters)-1; i++ {
+		if letters[i] == letters[i+1] {
+			letters[i] = "+"
+			letters = append(letters[:i+1], letters[i+2:]...)
 			i--
 		}
 	}
-	return string(runes)
+	return strings.Join(letters, "")
 }
 
 func shuffleString(input string) string {
-	runes := []rune(input)
-	rand.Shuffle(len(runes), func(i, j int) {
-		runes[i], runes[j] = runes[j], runes[i]
+	letters := graphemes(input)
+	rand.Shuffle(len(letters), func(i, j int) {
+		letters[i], letters[j] = letters[j], letters[i]
 	})
-	return string(runes)
+	return strings.Join(letters, "")
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package main
+
+import "sync"
+
+// This is a copy of the worker pool of Lab4 rather than an import: Lab3 is
+// built from its own directory without a module, so it cannot import the
+// workerpool module of Lab4, and that pool prints every job to stdout,
+// which would mix with output written to "-". Keep the two in step.
+
+// Job is a unit of work for a WorkerPool, as in Lab4.
+type Job struct {
+	Id          int
+	Description string
+	Run         func()
+}
+
+// WorkerPool runs jobs sent to Jobs on WorkersNum goroutines. Senders add
+// every job to Wg and the workers mark it done; closing Jobs stops them.
+type WorkerPool struct {
+	Jobs       chan Job
+	WorkersNum int
+	Wg         *sync.WaitGroup
+}
+
+func NewWorkerPool(workersNum int, group *sync.WaitGroup) *WorkerPool {
+	return &WorkerPool{
+		Jobs:       make(chan Job, workersNum),
+		WorkersNum: workersNum,
+		Wg:         group,
+	}
+}
+
+func (wp *WorkerPool) Run() {
+	for i := 0; i < wp.WorkersNum; i++ {
+		go func() {
+			for job := range wp.Jobs {
+				job.Run()
+				wp.Wg.Done()
+			}
+		}()
+	}
+}
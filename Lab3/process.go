@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxLineLength = 1 << 20
+	defaultChunkLines    = 1000
+)
+
+// Processor transforms text line by line without holding the whole input
+// in memory.
+type Processor struct {
+	Pipeline      Pipeline
+	MaxLineLength int
+	// Progress receives periodic progress reports; nil disables them.
+	Progress io.Writer
+	// Diffs keeps the changed lines, up to maxStoredDiffs, in the Stats.
+	Diffs bool
+	// Diff, if set, receives every line before and after processing, in
+	// order, as the output is written.
+	Diff func(LineDiff)
+	// InputEncoding is the encoding of the input; nil detects it.
+	InputEncoding *Encoding
+	// OutputEncoding is the encoding of the output; nil means UTF-8, unless
+	// KeepEncoding writes the output in the encoding of the input.
+	OutputEncoding *Encoding
+	KeepEncoding   bool
+	// Workers above 1 process chunks of ChunkLines lines concurrently; the
+	// output keeps the input order.
+	Workers    int
+	ChunkLines int
+	// Tokenizer splits lines into words; nil means the legacy tokenizer,
+	// which splits on spaces and commas and joins words with "-".
+	Tokenizer *Tokenizer
+	// Format is text, markdown, html or json; auto or "" picks it from the
+	// extension of each file. Only the human text of a document is
+	// transformed, with its separators kept.
+	Format string
+}
+
+// forFile returns the Processor to use for inputPath, with its format
+// resolved.
+func (p *Processor) forFile(inputPath string) *Processor {
+	file := *p
+	file.Format = formatOf(p.Format, inputPath)
+	if documentFormats[file.Format] != nil {
+		tokenizer := legacyTokenizer
+		if p.Tokenizer != nil {
+			tokenizer = p.Tokenizer
+		}
+		preserved := *tokenizer
+		preserved.Preserve, preserved.Joiner = true, ""
+		preserved.EscapeJoiner, preserved.UnescapeSplit = false, false
+		file.Tokenizer = &preserved
+	}
+	return &file
+}
+
+// ProcessLine splits a line into words, runs every word through the
+// pipeline and joins the results back together.
+func (p *Processor) ProcessLine(line string) string {
+	return p.processLine(line, nil, nil)
+}
+
+// processLine is ProcessLine that also records the line in stats, if any.
+// A document, if any, picks the text of the line to transform.
+func (p *Processor) processLine(line string, stats *Stats, doc document) string {
+	var result string
+	if doc != nil {
+		result = doc.Line(line, func(text string) string { return p.processWords(text, stats) })
+	} else {
+		result = p.processWords(line, stats)
+	}
+	if stats != nil {
+		stats.Lines++
+		if p.Diffs {
+			stats.addDiff(LineDiff{Line: stats.Lines, Before: line, After: result})
+		}
+	}
+	return result
+}
+
+// processWords runs the words of text through the pipeline.
+func (p *Processor) processWords(text string, stats *Stats) string {
+	tokenizer := p.Tokenizer
+	if tokenizer == nil {
+		tokenizer = legacyTokenizer
+	}
+	tokens := tokenizer.Tokenize(text)
+	for i, token := range tokens {
+		if !token.Word {
+			continue
+		}
+		word := token.Text
+		if stats != nil {
+			stats.addWord(word)
+		}
+		for _, step := range p.Pipeline {
+			if _, ok := step.(doubleTransform); ok && stats != nil {
+				stats.DoubleLetters += doubleLetterCount(word)
+			}
+			word = step.Apply(word)
+		}
+		tokens[i].Text = word
+	}
+	return tokenizer.Join(tokens)
+}
+
+// Process copies r to w line by line through ProcessLine and returns the
+// stats of the text. total is the input size in bytes used for progress
+// reports, or 0 when unknown. Every line keeps its ending, LF or CRLF, and a
+// missing newline at the end of the input is preserved.
+func (p *Processor) Process(r io.Reader, w io.Writer, total int64) (stats *Stats, err error) {
+	maxLine := p.MaxLineLength
+	if maxLine <= 0 {
+		maxLine = defaultMaxLineLength
+	}
+
+	source := bufio.NewReaderSize(r, detectionSample)
+	sample, _ := source.Peek(detectionSample)
+	enc, bom := DetectEncoding(sample)
+	if p.InputEncoding != nil && p.InputEncoding != enc {
+		enc, bom = p.InputEncoding, 0
+	}
+	source.Discard(bom)
+
+	splitter := &lineSplitter{consumed: int64(bom)}
+	var input io.Reader = source
+	if enc != UTF8 {
+		splitter.decoder = newDecodingReader(source, enc)
+		input = splitter.decoder
+	}
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLine)), maxLine)
+	scanner.Split(splitter.split)
+
+	outputEncoding := p.OutputEncoding
+	if p.KeepEncoding {
+		outputEncoding = enc
+	}
+	if outputEncoding != nil && outputEncoding != UTF8 {
+		encoder := newEncodingWriter(w, outputEncoding)
+		w = encoder
+		defer func() {
+			if err == nil {
+				err = encoder.Flush()
+			}
+		}()
+	}
+
+	writer := bufio.NewWriter(w)
+	progress := newProgressReporter(p.Progress, total)
+	var doc document
+	if newDocument := documentFormats[p.Format]; newDocument != nil {
+		doc = newDocument()
+	}
+	// A document carries state from line to line, so it is read in order.
+	if p.Workers > 1 && doc == nil {
+		return p.processChunks(scanner, splitter, writer, progress, maxLine)
+	}
+	stats = newStats()
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		line := scanner.Text()
+		result := p.processLine(line, stats, doc)
+		if p.Diff != nil {
+			p.Diff(LineDiff{Line: lines, Before: line, After: result})
+		}
+		writer.WriteString(result)
+		if _, err := writer.WriteString(splitter.terminator); err != nil {
+			return nil, err
+		}
+		progress.update(splitter.position(), lines)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("рядок %d довший за %d байт", lines+1, maxLine)
+		}
+		return nil, err
+	}
+	stats.Unterminated = lines > 0 && splitter.unterminated
+	progress.finish(splitter.position(), lines)
+	return stats, writer.Flush()
+}
+
+// lineChunk is a run of consecutive lines processed by one job.
+type lineChunk struct {
+	lines       []string
+	terminators []string
+	// before keeps the input lines when the Processor reports diffs.
+	before   []string
+	stats    *Stats
+	consumed int64
+	done     chan struct{}
+}
+
+// processChunks is the concurrent form of Process. A reader goroutine
+// hands chunks of lines to a worker pool and queues them in input order;
+// the caller writes each chunk once it is done. The queue is bounded, so
+// at most a few chunks per worker are held in memory.
+func (p *Processor) processChunks(scanner *bufio.Scanner, splitter *lineSplitter, writer *bufio.Writer, progress *progressReporter, maxLine int) (*Stats, error) {
+	chunkLines := p.ChunkLines
+	if chunkLines <= 0 {
+		chunkLines = defaultChunkLines
+	}
+
+	var wg sync.WaitGroup
+	pool := NewWorkerPool(p.Workers, &wg)
+	pool.Run()
+	queue := make(chan *lineChunk, 2*p.Workers)
+	stop := make(chan struct{})
+	var readErr error
+	read := 0
+
+	go func() {
+		defer close(queue)
+		chunk := &lineChunk{}
+		submit := func() bool {
+			c := chunk
+			c.consumed = splitter.position()
+			c.stats = newStats()
+			c.done = make(chan struct{})
+			chunk = &lineChunk{}
+			wg.Add(1)
+			pool.Jobs <- Job{Id: read, Description: fmt.Sprintf("рядки до %d", read), Run: func() {
+				for i, line := range c.lines {
+					c.lines[i] = p.processLine(line, c.stats, nil)
+				}
+				close(c.done)
+			}}
+			select {
+			case queue <- c:
+				return true
+			case <-stop:
+				return false
+			}
+		}
+		for scanner.Scan() {
+			read++
+			if p.Diff != nil {
+				chunk.before = append(chunk.before, scanner.Text())
+			}
+			chunk.lines = append(chunk.lines, scanner.Text())
+			chunk.terminators = append(chunk.terminators, splitter.terminator)
+			if len(chunk.lines) == chunkLines && !submit() {
+				break
+			}
+		}
+		readErr = scanner.Err()
+		if len(chunk.lines) > 0 {
+			submit()
+		}
+		wg.Wait()
+		close(pool.Jobs)
+	}()
+
+	stats := newStats()
+	lines := 0
+	var writeErr error
+	for c := range queue {
+		<-c.done
+		stats.merge(c.stats)
+		for i, line := range c.lines {
+			lines++
+			if p.Diff != nil {
+				p.Diff(LineDiff{Line: lines, Before: c.before[i], After: line})
+			}
+			writer.WriteString(line)
+			if _, err := writer.WriteString(c.terminators[i]); err != nil {
+				writeErr = err
+				break
+			}
+		}
+		if writeErr != nil {
+			close(stop)
+			for range queue {
+			}
+			return nil, writeErr
+		}
+		progress.update(c.consumed, lines)
+	}
+	if readErr != nil {
+		if errors.Is(readErr, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("рядок %d довший за %d байт", read+1, maxLine)
+		}
+		return nil, readErr
+	}
+	stats.Unterminated = lines > 0 && splitter.unterminated
+	progress.finish(splitter.position(), lines)
+	return stats, writer.Flush()
+}
+
+// lineSplitter wraps bufio.ScanLines, counting consumed bytes and
+// remembering the ending of the last line split and whether the input
+// lacked a trailing newline.
+type lineSplitter struct {
+	consumed int64
+	// terminator is "\n" or "\r\n", or what is left after the last line of
+	// an input without a trailing newline: "" or a lone "\r".
+	terminator   string
+	unterminated bool
+	// decoder, when the input is converted to UTF-8, counts the source bytes.
+	decoder *decodingReader
+}
+
+// position is the number of input bytes read so far.
+func (s *lineSplitter) position() int64 {
+	if s.decoder != nil {
+		return s.decoder.consumed
+	}
+	return s.consumed
+}
+
+func (s *lineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if token != nil {
+		s.terminator = string(data[len(token):advance])
+		if atEOF && bytes.IndexByte(data[:advance], '\n') < 0 {
+			s.unterminated = true
+		}
+	}
+	s.consumed += int64(advance)
+	return advance, token, err
+}
+
+// processFile streams inputPath into a temporary file next to outputPath and
+// renames it into place only when processing succeeded, so readers never see
+// a partially written output. "-" stands for stdin or stdout.
+func processFile(inputPath, outputPath string, p *Processor) (stats *Stats, err error) {
+	input, total, closeInput, err := openInput(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput()
+	p = p.forFile(inputPath)
+
+	if outputPath == stdioPath {
+		return p.Process(input, os.Stdout, total)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if stats, err = p.Process(input, tmp, total); err != nil {
+		return nil, err
+	}
+	if err = tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, err
+	}
+	return stats, os.Rename(tmp.Name(), outputPath)
+}
+
+// previewFile processes inputPath without writing the result anywhere, for
+// a dry run; the Processor should report diffs.
+func previewFile(inputPath string, p *Processor) (*Stats, error) {
+	input, total, closeInput, err := openInput(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput()
+	return p.forFile(inputPath).Process(input, io.Discard, total)
+}
+
+// openInput opens a file, or stdin for "-", and returns its size when known.
+func openInput(path string) (io.Reader, int64, func(), error) {
+	if path == stdioPath {
+		return os.Stdin, 0, func() {}, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	var total int64
+	if info, err := file.Stat(); err == nil {
+		total = info.Size()
+	}
+	return file, total, func() { file.Close() }, nil
+}
+
+type progressReporter struct {
+	out        io.Writer
+	total      int64
+	lastReport time.Time
+}
+
+const progressInterval = 500 * time.Millisecond
+
+func newProgressReporter(out io.Writer, total int64) *progressReporter {
+	return &progressReporter{out: out, total: total, lastReport: time.Now()}
+}
+
+func (r *progressReporter) update(done int64, lines int) {
+	if r.out == nil || time.Since(r.lastReport) < progressInterval {
+		return
+	}
+	r.lastReport = time.Now()
+	r.print(done, lines, "\r")
+}
+
+func (r *progressReporter) finish(done int64, lines int) {
+	if r.out != nil {
+		r.print(done, lines, "\r")
+		fmt.Fprintln(r.out)
+	}
+}
+
+func (r *progressReporter) print(done int64, lines int, prefix string) {
+	if r.total > 0 {
+		fmt.Fprintf(r.out, "%sОброблено %s з %s (%.0f%%), рядків: %d", prefix, formatBytes(done), formatBytes(r.total), float64(done)*100/float64(r.total), lines)
+		return
+	}
+	fmt.Fprintf(r.out, "%sОброблено %s, рядків: %d", prefix, formatBytes(done), lines)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d Б", n)
+	}
+	value, suffix := float64(n)/unit, "КБ"
+	for _, next := range []string{"МБ", "ГБ", "ТБ"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
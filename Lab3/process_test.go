@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProcessKeepsLineEndings(t *testing.T) {
+	inputs := []string{
+		"",
+		"aa bb\n",
+		"aa bb\r\nкк\r\n",
+		"aa\r\n\r\nbb",
+		"mixed\nendings\r\nhere\r\n",
+		"lone cr at the end\r",
+		"\n\n",
+	}
+	for _, workers := range []int{1, 3} {
+		for _, input := range inputs {
+			p := &Processor{Pipeline: Pipeline{}, Tokenizer: &Tokenizer{Preserve: true}, Workers: workers, ChunkLines: 1}
+			var out strings.Builder
+			if _, err := p.Process(strings.NewReader(input), &out, 0); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != input {
+				t.Errorf("workers %d: %q came out as %q", workers, input, out.String())
+			}
+		}
+	}
+}
+
+func TestProcessCRLFLinesHaveNoCR(t *testing.T) {
+	p := &Processor{Pipeline: pipelineOf(t, "reverse"), Tokenizer: &Tokenizer{Preserve: true}, Diffs: true}
+	var out strings.Builder
+	stats, err := p.Process(strings.NewReader("ab cd\r\nef\r\n"), &out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ba dc\r\nfe\r\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if stats.Lines != 2 || stats.Diffs[0].Before != "ab cd" || stats.Unterminated {
+		t.Errorf("got %d lines, first %q, unterminated %v", stats.Lines, stats.Diffs[0].Before, stats.Unterminated)
+	}
+}
+
+func TestProcessStoresChangedLines(t *testing.T) {
+	// Every third line has a doubled letter; only those are kept, up to
+	// maxStoredDiffs, and all of them are counted.
+	var input strings.Builder
+	for i := range 3 * (maxStoredDiffs + 10) {
+		if i%3 == 0 {
+			fmt.Fprintf(&input, "класс %d\n", i+1)
+		} else {
+			input.WriteString("слово\n")
+		}
+	}
+	for _, workers := range []int{1, 3} {
+		p := &Processor{Pipeline: pipelineOf(t, "double"), Tokenizer: &Tokenizer{Preserve: true}, Diffs: true, Workers: workers, ChunkLines: 7}
+		stats, err := p.Process(strings.NewReader(input.String()), &strings.Builder{}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.ChangedLines != maxStoredDiffs+10 || len(stats.Diffs) != maxStoredDiffs {
+			t.Fatalf("workers %d: %d changed, %d stored", workers, stats.ChangedLines, len(stats.Diffs))
+		}
+		for i, d := range stats.Diffs {
+			if want := fmt.Sprintf("класс %d", 3*i+1); d.Line != 3*i+1 || d.Before != want || !strings.HasPrefix(d.After, "кла+ ") {
+				t.Errorf("workers %d: diff %d is %+v", workers, i, d)
+			}
+		}
+	}
+}
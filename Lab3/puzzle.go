@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// scrambleAttempts bounds the random shuffles tried before a scramble
+// falls back to rotating the word.
+const scrambleAttempts = 20
+
+// Puzzle is a scrambled word and its answer.
+type Puzzle struct {
+	Scramble string
+	Answer   string
+}
+
+// PuzzleSet is a puzzle sheet built from a text.
+type PuzzleSet struct {
+	Puzzles []Puzzle
+	// Ambiguous lists words left out because the dictionary has another
+	// word with the same letters, so the scramble would have two answers.
+	Ambiguous []string
+	// Anagrams groups the words of the text that are anagrams of each other.
+	Anagrams [][]string
+}
+
+// wordRecorder is a transform that collects the words it sees, in order.
+type wordRecorder struct {
+	words *[]string
+}
+
+func (r wordRecorder) Name() string { return "record" }
+
+func (r wordRecorder) Apply(word string) string {
+	*r.words = append(*r.words, word)
+	return word
+}
+
+// collectWords reads the words of a file with the encoding detection and
+// tokenizer of the processor.
+func collectWords(path string, tokenizer *Tokenizer) ([]string, error) {
+	var words []string
+	p := &Processor{Pipeline: Pipeline{wordRecorder{&words}}, Tokenizer: tokenizer}
+	_, err := previewFile(path, p)
+	return words, err
+}
+
+// BuildPuzzles picks up to count distinct words of at least minLength
+// letters in the order they appear and scrambles them so that every
+// scramble differs from its word and, with a dictionary, is not a word
+// itself and has only one answer.
+func BuildPuzzles(words []string, dictionary map[string]bool, count, minLength int, rng *rand.Rand) PuzzleSet {
+	var set PuzzleSet
+	seen := map[string]bool{}
+	groups := map[string][]string{}
+	var signatures []string
+
+	// Words of the dictionary that share letters, for the uniqueness check.
+	dictGroups := map[string][]string{}
+	for word := range dictionary {
+		signature := letterSignature(word)
+		dictGroups[signature] = append(dictGroups[signature], word)
+	}
+
+	for _, word := range words {
+		word = strings.ToLower(word)
+		letters := graphemes(word)
+		if seen[word] || !isPlainWord(letters) {
+			continue
+		}
+		seen[word] = true
+
+		signature := letterSignature(word)
+		if len(groups[signature]) == 0 {
+			signatures = append(signatures, signature)
+		}
+		groups[signature] = append(groups[signature], word)
+
+		if len(set.Puzzles) == count || len(letters) < minLength {
+			continue
+		}
+		if others := slices.DeleteFunc(slices.Clone(dictGroups[signature]), func(w string) bool { return w == word }); len(others) > 0 {
+			set.Ambiguous = append(set.Ambiguous, word)
+			continue
+		}
+		if scramble, ok := scrambleWord(letters, dictionary, rng); ok {
+			set.Puzzles = append(set.Puzzles, Puzzle{Scramble: scramble, Answer: word})
+		}
+	}
+
+	for _, signature := range signatures {
+		if group := groups[signature]; len(group) > 1 {
+			set.Anagrams = append(set.Anagrams, group)
+		}
+	}
+	return set
+}
+
+// scrambleWord shuffles letters until the result differs from the word
+// and is not in the dictionary. It fails only for words such as "ааа"
+// that have no other arrangement.
+func scrambleWord(letters []string, dictionary map[string]bool, rng *rand.Rand) (string, bool) {
+	word := strings.Join(letters, "")
+	candidate := slices.Clone(letters)
+	for attempt := 0; attempt <= scrambleAttempts+len(letters); attempt++ {
+		if attempt < scrambleAttempts {
+			rng.Shuffle(len(candidate), func(i, j int) {
+				candidate[i], candidate[j] = candidate[j], candidate[i]
+			})
+		} else {
+			// Rotations are tried last so that a valid scramble is always
+			// found when one exists among them.
+			shift := attempt - scrambleAttempts + 1
+			candidate = append(slices.Clone(letters[shift%len(letters):]), letters[:shift%len(letters)]...)
+		}
+		scramble := strings.Join(candidate, "")
+		if scramble != word && !dictionary[scramble] {
+			return scramble, true
+		}
+	}
+	return "", false
+}
+
+func isPlainWord(letters []string) bool {
+	for _, letter := range letters {
+		if !unicode.IsLetter(firstRune(letter)) {
+			return false
+		}
+	}
+	return len(letters) > 0
+}
+
+// letterSignature is the same for all anagrams of a word.
+func letterSignature(word string) string {
+	letters := graphemes(strings.ToLower(word))
+	slices.Sort(letters)
+	return strings.Join(letters, "")
+}
+
+// WriteSheet writes the scrambles to solve and the anagram groups.
+func (s PuzzleSet) WriteSheet(w io.Writer) {
+	fmt.Fprintln(w, "Розгадайте слова:")
+	fmt.Fprintln(w)
+	for i, p := range s.Puzzles {
+		fmt.Fprintf(w, "%3d. %s\n", i+1, p.Scramble)
+	}
+	if len(s.Anagrams) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Анаграми, знайдені в тексті:")
+		for _, group := range s.Anagrams {
+			fmt.Fprintln(w, "  -", strings.Join(group, ", "))
+		}
+	}
+}
+
+// WriteAnswers writes the answer key, numbered like the sheet.
+func (s PuzzleSet) WriteAnswers(w io.Writer) {
+	fmt.Fprintln(w, "Відповіді:")
+	fmt.Fprintln(w)
+	for i, p := range s.Puzzles {
+		fmt.Fprintf(w, "%3d. %s → %s\n", i+1, p.Scramble, p.Answer)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildPuzzles(t *testing.T) {
+	words := strings.Fields("Кіт сидить на вікні , рука і кура мова Мова слово ааа лист стил вода ялинка")
+	dictionary := map[string]bool{"кура": true, "рука": true, "вода": true, "адов": true, "лист": true, "стил": true}
+	build := func() PuzzleSet {
+		return BuildPuzzles(words, dictionary, 6, 3, rand.New(rand.NewPCG(7, 11)))
+	}
+	set := build()
+
+	// "рука", "кура", "лист", "стил" and "вода" have anagrams in the
+	// dictionary, "ааа" cannot be scrambled and "на" is too short.
+	want := []string{"кіт", "сидить", "вікні", "мова", "слово", "ялинка"}
+	var answers []string
+	for _, p := range set.Puzzles {
+		answers = append(answers, p.Answer)
+		if letterSignature(p.Scramble) != letterSignature(p.Answer) {
+			t.Errorf("%q is not a scramble of %q", p.Scramble, p.Answer)
+		}
+		if p.Scramble == p.Answer || dictionary[p.Scramble] {
+			t.Errorf("%q is a poor scramble of %q", p.Scramble, p.Answer)
+		}
+	}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("answers %q, want %q", answers, want)
+	}
+	if wantAmbiguous := []string{"рука", "кура", "лист", "стил", "вода"}; !reflect.DeepEqual(set.Ambiguous, wantAmbiguous) {
+		t.Errorf("ambiguous %q, want %q", set.Ambiguous, wantAmbiguous)
+	}
+	if wantAnagrams := [][]string{{"рука", "кура"}, {"лист", "стил"}}; !reflect.DeepEqual(set.Anagrams, wantAnagrams) {
+		t.Errorf("anagrams %q, want %q", set.Anagrams, wantAnagrams)
+	}
+
+	// The same seed gives the same sheet.
+	if again := build(); !reflect.DeepEqual(again, set) {
+		t.Errorf("seeded sets differ:\n%v\n%v", set, again)
+	}
+
+	// The answer key is numbered like the sheet.
+	var sheet, key strings.Builder
+	set.WriteSheet(&sheet)
+	set.WriteAnswers(&key)
+	for i, p := range set.Puzzles {
+		line := fmt.Sprintf("%3d. %s", i+1, p.Scramble)
+		if !strings.Contains(sheet.String(), line+"\n") || !strings.Contains(key.String(), line+" → "+p.Answer+"\n") {
+			t.Errorf("puzzle %d (%s → %s) is numbered differently:\n%s\n%s", i+1, p.Scramble, p.Answer, sheet.String(), key.String())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Inverter is implemented by transforms that can be undone. Inverse fails
+// when the transform is configured in a lossy way.
+type Inverter interface {
+	Inverse() (Transform, error)
+}
+
+// Inverse returns the pipeline that restores words produced by p: the
+// inverses of its steps in reverse order.
+func (p Pipeline) Inverse() (Pipeline, error) {
+	inverse := make(Pipeline, 0, len(p))
+	for i := len(p) - 1; i >= 0; i-- {
+		inverter, ok := p[i].(Inverter)
+		if !ok {
+			return nil, fmt.Errorf("крок %q не можна обернути", p[i].Name())
+		}
+		t, err := inverter.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		inverse = append(inverse, t)
+	}
+	return inverse, nil
+}
+
+// invertibleFunc is a wordFunc with a known inverse function.
+type invertibleFunc struct {
+	wordFunc
+	inverse func(string) string
+}
+
+func (f invertibleFunc) Inverse() (Transform, error) {
+	return invertibleFunc{wordFunc{f.name, f.inverse}, f.fn}, nil
+}
+
+func (c caesarTransform) Inverse() (Transform, error) {
+	return caesarTransform{shift: -c.shift}, nil
+}
+
+// doubleTransform replaces doubled letters with '+'. The legacy form drops
+// the letter, so it cannot be undone; with escape it writes "+x" for "xx"
+// and escapes literal '+' and '\' with a backslash.
+type doubleTransform struct {
+	escape bool
+}
+
+func (d doubleTransform) Name() string { return "double" }
+
+func (d doubleTransform) Apply(word string) string {
+	if !d.escape {
+		return replaceDoubleLetters(word)
+	}
+	return escapeDoubleLetters(word)
+}
+
+func (d doubleTransform) Inverse() (Transform, error) {
+	if !d.escape {
+		return nil, fmt.Errorf("крок \"double\" втрачає літери; для декодування задайте escape=true")
+	}
+	return wordFunc{"double", unescapeDoubleLetters}, nil
+}
+
+func escapeDoubleLetters(word string) string {
+	letters := graphemes(word)
+	var b strings.Builder
+	for i := 0; i < len(letters); i++ {
+		switch letter := letters[i]; {
+		case letter == "+" || letter == "\\":
+			b.WriteString("\\" + letter)
+		case i+1 < len(letters) && letters[i+1] == letter:
+			b.WriteString("+" + letter)
+			i++
+		default:
+			b.WriteString(letter)
+		}
+	}
+	return b.String()
+}
+
+// unescapeDoubleLetters undoes escapeDoubleLetters. Malformed input, such
+// as a trailing '+' or '\', is kept as it is.
+func unescapeDoubleLetters(word string) string {
+	letters := graphemes(word)
+	var b strings.Builder
+	for i := 0; i < len(letters); i++ {
+		letter := letters[i]
+		if i+1 == len(letters) || (letter != "+" && letter != "\\") {
+			b.WriteString(letter)
+			continue
+		}
+		i++
+		if letter == "+" {
+			b.WriteString(letters[i])
+		}
+		b.WriteString(letters[i])
+	}
+	return b.String()
+}
+
+// shuffleTransform permutes the letters of a word. Without a seed or key it
+// uses the global random source. A seed makes the output reproducible: every
+// word gets its own generator derived from the seed and the word. A key makes
+// the shuffle reversible: the permutation depends only on the key and on the
+// letters of the word, which the shuffled word still has, and characters
+// other than letters and digits keep their places.
+type shuffleTransform struct {
+	seed   uint64
+	seeded bool
+	key    []byte
+	invert bool
+}
+
+func (s shuffleTransform) Name() string { return "shuffle" }
+
+func (s shuffleTransform) Apply(word string) string {
+	letters := graphemes(word)
+	switch {
+	case s.key != nil:
+		// Only letters and digits move, so punctuation inside a word stays
+		// where the tokenizer expects it when the text is decoded.
+		var positions []int
+		var movable []string
+		for i, letter := range letters {
+			if isWordRune(firstRune(letter)) {
+				positions = append(positions, i)
+				movable = append(movable, letter)
+			}
+		}
+		for i, j := range s.keyedPermutation(movable) {
+			if s.invert {
+				letters[positions[j]] = movable[i]
+			} else {
+				letters[positions[i]] = movable[j]
+			}
+		}
+		return strings.Join(letters, "")
+	case s.seeded:
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		rng := rand.New(rand.NewPCG(s.seed, h.Sum64()))
+		rng.Shuffle(len(letters), func(i, j int) {
+			letters[i], letters[j] = letters[j], letters[i]
+		})
+		return strings.Join(letters, "")
+	default:
+		return shuffleString(word)
+	}
+}
+
+func (s shuffleTransform) keyedPermutation(letters []string) []int {
+	sorted := slices.Clone(letters)
+	slices.Sort(sorted)
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(strings.Join(sorted, "\x00")))
+	var seed [32]byte
+	copy(seed[:], mac.Sum(nil))
+	return rand.New(rand.NewChaCha8(seed)).Perm(len(letters))
+}
+
+func (s shuffleTransform) Inverse() (Transform, error) {
+	if s.key == nil {
+		return nil, fmt.Errorf("крок \"shuffle\" без ключа не можна обернути; задайте key")
+	}
+	return shuffleTransform{key: s.key, invert: !s.invert}, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var roundTripInputs = []string{
+	"книжка ввічливо ззовні",
+	"Hello, wood room! Coffee & toffee.",
+	"a+b = c, 2++2, c++ і \\n",
+	`\\ \+ +\ ++ \\\\ ааа+бб\\вв`,
+	"п'ять ківі, don't stop; 3.14 і 1,5",
+	"ааа+бб\\вв — рядок без пробілів,і,коми",
+	"",
+}
+
+// keyedPipeline builds the -pipeline spec as main does with -key.
+func keyedPipeline(t *testing.T, spec, key string) (encode, decode Pipeline) {
+	t.Helper()
+	defaults := map[string]Params{"shuffle": {"key": key}, "double": {"escape": "true"}}
+	encode, err := loadPipeline("", spec, true, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decode, err = encode.Inverse(); err != nil {
+		t.Fatal(err)
+	}
+	return encode, decode
+}
+
+func runProcessor(t *testing.T, p *Processor, text string) string {
+	t.Helper()
+	var out strings.Builder
+	if _, err := p.Process(strings.NewReader(text), &out, 0); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestKeyedRoundTrip(t *testing.T) {
+	for _, spec := range []string{"double,shuffle", "shuffle,double", "double", "shuffle,reverse,caesar:shift=5"} {
+		encode, decode := keyedPipeline(t, spec, "секрет")
+		// With -key the escapes stay inside words, as main sets it up.
+		tokenizer := &Tokenizer{Split: defaultSplit, Preserve: true, WordChars: `+\`}
+		for _, input := range roundTripInputs {
+			encoded := runProcessor(t, &Processor{Pipeline: encode, Tokenizer: tokenizer}, input)
+			decoded := runProcessor(t, &Processor{Pipeline: decode, Tokenizer: tokenizer}, encoded)
+			if decoded != input {
+				t.Errorf("%s: %q encoded to %q decoded to %q", spec, input, encoded, decoded)
+			}
+		}
+	}
+}
+
+func TestKeyedRoundTripLegacy(t *testing.T) {
+	encode, decode := keyedPipeline(t, defaultPipeline, "key")
+	// Decoding swaps the separators of the legacy mode, as main does.
+	encoder := &Tokenizer{Split: defaultSplit, Joiner: defaultJoiner, WordChars: `+\`, EscapeJoiner: true}
+	decoder := &Tokenizer{Split: defaultJoiner, Joiner: " ", WordChars: `+\`, UnescapeSplit: true}
+	inputs := []string{
+		"книжка ввічливо ззовні", `a+b c\\d ++ \+`, "Hello wood room",
+		"будь-ласка що-небудь", "-на-початку і в-кінці- --", `a\-b c-\ \`,
+	}
+	for _, input := range inputs {
+		encoded := runProcessor(t, &Processor{Pipeline: encode, Tokenizer: encoder}, input)
+		decoded := runProcessor(t, &Processor{Pipeline: decode, Tokenizer: decoder}, encoded)
+		if decoded != input {
+			t.Errorf("%q encoded to %q decoded to %q", input, encoded, decoded)
+		}
+	}
+}
+
+func TestKeyedShuffleDependsOnKey(t *testing.T) {
+	word := "перемішування"
+	a := shuffleTransform{key: []byte("a")}.Apply(word)
+	if a == word {
+		t.Errorf("shuffle left %q unchanged", word)
+	}
+	if b := (shuffleTransform{key: []byte("b")}).Apply(word); a == b {
+		t.Errorf("keys a and b shuffle %q the same: %q", word, a)
+	}
+	if wrong := (shuffleTransform{key: []byte("b"), invert: true}).Apply(a); wrong == word {
+		t.Errorf("a wrong key decoded %q", a)
+	}
+}
+
+func TestEscapeDoubleLetters(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"книжка", "книжка"},
+		{"ввічливо", "+вічливо"},
+		{"ааа", "+аа"},
+		{"a+b", `a\+b`},
+		{`a\b`, `a\\b`},
+		{"++", `\+\+`},
+		{"coffee", "co+f+e"},
+	}
+	for _, tt := range tests {
+		got := escapeDoubleLetters(tt.in)
+		if got != tt.want {
+			t.Errorf("escapeDoubleLetters(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := unescapeDoubleLetters(got); back != tt.in {
+			t.Errorf("unescapeDoubleLetters(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+}
+
+func TestInverseRequiresReversibleSteps(t *testing.T) {
+	for _, spec := range []string{"double", "shuffle", "upper", "shuffle:seed=1"} {
+		if _, err := pipelineOf(t, spec).Inverse(); err == nil {
+			t.Errorf("%s: got an inverse of a lossy step", spec)
+		}
+	}
+}
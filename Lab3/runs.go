@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// runsTransform generalizes the double-letter rule to runs of repeated
+// characters. In the replace mode every run of at least min (and at most
+// max, when max > 0) equal characters is written through the template,
+// where {char} stands for the repeated character and {count} for the run
+// length. The rle mode writes runs as "<count><char>", escaping digits and
+// '\' with a backslash, and the unrle mode decodes that form.
+type runsTransform struct {
+	mode       string
+	min, max   int
+	ignoreCase bool
+	template   string
+}
+
+func newRunsTransform(p *paramReader) (Transform, error) {
+	r := runsTransform{mode: p.text("mode", "replace"), template: p.text("template", "{char}x{count}")}
+	var err error
+	if r.min, err = p.int("min", 2); err != nil {
+		return nil, err
+	}
+	if r.max, err = p.int("max", 0); err != nil {
+		return nil, err
+	}
+	if r.ignoreCase, err = p.bool("ignorecase", false); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case r.mode != "replace" && r.mode != "rle" && r.mode != "unrle":
+		return nil, fmt.Errorf("параметр mode кроку %q має бути replace, rle або unrle, отримано %q", p.step, r.mode)
+	case r.min < 1:
+		return nil, fmt.Errorf("параметр min кроку %q має бути не меншим за 1", p.step)
+	case r.max != 0 && r.max < r.min:
+		return nil, fmt.Errorf("параметр max кроку %q менший за min", p.step)
+	case r.mode != "replace" && r.ignoreCase:
+		return nil, fmt.Errorf("крок %q у режимі %s не підтримує ignorecase: регістр літер було б втрачено", p.step, r.mode)
+	}
+	return r, nil
+}
+
+func (r runsTransform) Name() string { return "runs" }
+
+func (r runsTransform) Apply(word string) string {
+	switch r.mode {
+	case "rle":
+		return r.encode(word)
+	case "unrle":
+		return decodeRuns(word)
+	}
+
+	var b strings.Builder
+	letters := graphemes(word)
+	for i := 0; i < len(letters); {
+		n := r.runLength(letters, i)
+		if n >= r.min && (r.max == 0 || n <= r.max) {
+			b.WriteString(strings.NewReplacer("{char}", letters[i], "{count}", strconv.Itoa(n)).Replace(r.template))
+		} else {
+			b.WriteString(strings.Join(letters[i:i+n], ""))
+		}
+		i += n
+	}
+	return b.String()
+}
+
+func (r runsTransform) Inverse() (Transform, error) {
+	switch r.mode {
+	case "rle":
+		return runsTransform{mode: "unrle", min: r.min}, nil
+	case "unrle":
+		return runsTransform{mode: "rle", min: r.min}, nil
+	}
+	return nil, fmt.Errorf("крок \"runs\" у режимі replace не можна обернути; використайте mode=rle")
+}
+
+// runLength counts the characters equal to letters[i] starting at i.
+func (r runsTransform) runLength(letters []string, i int) int {
+	n := 1
+	for i+n < len(letters) && (letters[i+n] == letters[i] || r.ignoreCase && strings.EqualFold(letters[i+n], letters[i])) {
+		n++
+	}
+	return n
+}
+
+func (r runsTransform) encode(word string) string {
+	var b strings.Builder
+	letters := graphemes(word)
+	for i := 0; i < len(letters); {
+		n := r.runLength(letters, i)
+		letter := letters[i]
+		if letter == `\` || unicode.IsDigit(firstRune(letter)) {
+			letter = `\` + letter
+		}
+		if n >= r.min {
+			b.WriteString(strconv.Itoa(n) + letter)
+		} else {
+			b.WriteString(strings.Repeat(letter, n))
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// decodeRuns reverses the rle mode. Malformed runs are kept as they are:
+// a count without a character after it, and a count of zero or one longer
+// than the longest line Lab3 reads.
+func decodeRuns(word string) string {
+	var b strings.Builder
+	letters := graphemes(word)
+	for i := 0; i < len(letters); i++ {
+		start, count := i, 0
+		for i < len(letters) && len(letters[i]) == 1 && letters[i][0] >= '0' && letters[i][0] <= '9' {
+			count = min(count*10+int(letters[i][0]-'0'), defaultMaxLineLength+1)
+			i++
+		}
+		escaped := i+1 < len(letters) && letters[i] == `\`
+		if escaped {
+			i++
+		}
+		switch {
+		case i == len(letters) || (letters[i] == `\` && !escaped):
+			b.WriteString(strings.Join(letters[start:], ""))
+			return b.String()
+		case i == start || (i == start+1 && escaped):
+			b.WriteString(letters[i])
+		case count < 1 || count > defaultMaxLineLength:
+			b.WriteString(strings.Join(letters[start:i+1], ""))
+		default:
+			b.WriteString(strings.Repeat(letters[i], count))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunsRoundTrip(t *testing.T) {
+	words := []string{
+		"", "a", "aa", "aaa", "книжка", "ззовні", "ввічливо",
+		"a1", "a11", "111", "1aa2", "2024", "x99y", "12ab",
+		`\`, `\\`, `\\\`, `a\b`, `1\1`, `\1`, `\\1`,
+		"éé", "👍👍👍", "🇺🇦🇺🇦", "ааааааааааааааааааааааааааа",
+	}
+	for _, min := range []string{"1", "2", "3"} {
+		encode := pipelineOf(t, "runs:mode=rle:min="+min)
+		decode, err := encode.Inverse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, word := range words {
+			encoded := encode.Apply(word)
+			if decoded := decode.Apply(encoded); decoded != word {
+				t.Errorf("min=%s: %q encoded to %q decoded to %q", min, word, encoded, decoded)
+			}
+		}
+	}
+}
+
+func TestRunsEncode(t *testing.T) {
+	tests := []struct{ spec, in, want string }{
+		{"runs:mode=rle", "aaabcc", "3ab2c"},
+		{"runs:mode=rle:min=1", "aab", "2a1b"},
+		{"runs:mode=rle", "1112", `3\1\2`},
+		{"runs:mode=rle", `a\\b`, `a2\\b`},
+		{"runs:mode=unrle", `3ab2c`, "aaabcc"},
+		{"runs:mode=unrle", `3\1\2`, "1112"},
+		{"runs", "ааабб", "аx3бx2"},
+		{"runs:min=3:template={count}{char}", "ааабб", "3абб"},
+		{"runs:max=2", "ааабб", "ааабx2"},
+		{"runs:ignorecase=true", "AaAb", "Ax3b"},
+	}
+	for _, tt := range tests {
+		if got := pipelineOf(t, tt.spec).Apply(tt.in); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.spec, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRunsDecodeMalformed(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"3", "3"},
+		{"12", "12"},
+		{`\`, `\`},
+		{`3\`, `3\`},
+		{`a3\`, `a3\`},
+		{"0a", "0a"},
+		{`0\1`, `0\1`},
+		{"99999999999999999999a", "99999999999999999999a"},
+		{"1048577a", "1048577a"},
+		{"2ab3", "aab3"},
+	}
+	for _, tt := range tests {
+		if got := decodeRuns(tt.in); got != tt.want {
+			t.Errorf("decodeRuns(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := decodeRuns("1048576a"); len(got) != 1048576 {
+		t.Errorf("decodeRuns(\"1048576a\") has length %d, want 1048576", len(got))
+	}
+}
+
+func TestRunsErrors(t *testing.T) {
+	tests := []struct{ spec, want string }{
+		{"runs:mode=zip", "має бути replace, rle або unrle"},
+		{"runs:min=0", "не меншим за 1"},
+		{"runs:min=3:max=2", "менший за min"},
+		{"runs:mode=rle:ignorecase=true", "не підтримує ignorecase"},
+		{"runs:min=x", "min"},
+		{"runs:colour=red", "colour"},
+	}
+	for _, tt := range tests {
+		steps, err := ParsePipeline(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := BuildPipeline(steps); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tt.spec, err, tt.want)
+		}
+	}
+	if _, err := pipelineOf(t, "runs").Inverse(); err == nil {
+		t.Error("runs in the replace mode has an inverse")
+	}
+}
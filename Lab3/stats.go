@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// topCount is how many of the most frequent words and letters are reported.
+const topCount = 10
+
+// maxStoredDiffs is how many changed lines a Stats keeps; the rest are only
+// counted, so that a large file does not stay in memory.
+const maxStoredDiffs = 100
+
+// Stats describes the text of one processed file.
+type Stats struct {
+	Input         string
+	Output        string
+	Lines         int
+	Words         int
+	DoubleLetters int
+	words         map[string]int
+	letters       map[string]int
+	// Diffs holds the first maxStoredDiffs changed lines before and after
+	// processing, and ChangedLines counts them all, when the Processor
+	// collects them.
+	Diffs        []LineDiff
+	ChangedLines int
+	// Unterminated is set when the last line has no newline.
+	Unterminated bool
+}
+
+// LineDiff is a line before and after processing; Line counts from 1.
+type LineDiff struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Frequency is how often a word or letter occurs.
+type Frequency struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+func newStats() *Stats {
+	return &Stats{words: map[string]int{}, letters: map[string]int{}}
+}
+
+func (s *Stats) addWord(word string) {
+	word = strings.ToLower(word)
+	s.Words++
+	s.words[word]++
+	for _, letter := range graphemes(word) {
+		if unicode.IsLetter(firstRune(letter)) {
+			s.letters[letter]++
+		}
+	}
+}
+
+// addDiff records a line if processing changed it.
+func (s *Stats) addDiff(d LineDiff) {
+	if d.Before == d.After {
+		return
+	}
+	s.ChangedLines++
+	if len(s.Diffs) < maxStoredDiffs {
+		s.Diffs = append(s.Diffs, d)
+	}
+}
+
+// merge adds the stats of the lines that follow the ones s has seen.
+func (s *Stats) merge(other *Stats) {
+	for _, d := range other.Diffs[:min(len(other.Diffs), maxStoredDiffs-len(s.Diffs))] {
+		d.Line += s.Lines
+		s.Diffs = append(s.Diffs, d)
+	}
+	s.ChangedLines += other.ChangedLines
+	s.Lines += other.Lines
+	s.Words += other.Words
+	s.DoubleLetters += other.DoubleLetters
+	for word, n := range other.words {
+		s.words[word] += n
+	}
+	for letter, n := range other.letters {
+		s.letters[letter] += n
+	}
+}
+
+func (s *Stats) TopWords(n int) []Frequency {
+	return topFrequencies(s.words, n)
+}
+
+func (s *Stats) TopLetters(n int) []Frequency {
+	return topFrequencies(s.letters, n)
+}
+
+func topFrequencies(counts map[string]int, n int) []Frequency {
+	list := make([]Frequency, 0, len(counts))
+	for text, count := range counts {
+		list = append(list, Frequency{text, count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Text < list[j].Text
+	})
+	return list[:min(n, len(list))]
+}
+
+// doubleLetterCount counts the pairs of equal adjacent letters the double
+// step replaces in word.
+func doubleLetterCount(word string) int {
+	letters := graphemes(word)
+	n := 0
+	for i := 0; i < len(letters)-1; i++ {
+		if letters[i] == letters[i+1] {
+			n++
+			i++
+		}
+	}
+	return n
+}
+
+// ReportMode selects how much the program reports about processed files.
+type ReportMode int
+
+const (
+	ReportQuiet ReportMode = iota
+	ReportSummary
+	ReportVerbose
+	ReportJSON
+)
+
+func ParseReportMode(name string) (ReportMode, error) {
+	switch name {
+	case "quiet":
+		return ReportQuiet, nil
+	case "summary":
+		return ReportSummary, nil
+	case "verbose":
+		return ReportVerbose, nil
+	case "json":
+		return ReportJSON, nil
+	default:
+		return 0, fmt.Errorf("невідомий вид звіту %q (quiet, summary, verbose або json)", name)
+	}
+}
+
+// WriteText writes the stats of one file; verbose adds the changed lines.
+func (s *Stats) WriteText(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "Файл %s → %s: рядків %d, слів %d, замінено подвоєних літер %d\n", s.Input, s.Output, s.Lines, s.Words, s.DoubleLetters)
+	if s.Words > 0 {
+		fmt.Fprintln(w, "  Найчастіші слова:", formatFrequencies(s.TopWords(topCount)))
+		fmt.Fprintln(w, "  Найчастіші літери:", formatFrequencies(s.TopLetters(topCount)))
+	}
+	if verbose {
+		for _, d := range s.Diffs {
+			fmt.Fprintf(w, "  %d: - %s\n  %*s  + %s\n", d.Line, d.Before, len(fmt.Sprint(d.Line)), "", d.After)
+		}
+		if rest := s.ChangedLines - len(s.Diffs); rest > 0 {
+			fmt.Fprintf(w, "  … і ще змінених рядків: %d\n", rest)
+		}
+	}
+}
+
+func formatFrequencies(list []Frequency) string {
+	parts := make([]string, len(list))
+	for i, f := range list {
+		parts[i] = fmt.Sprintf("%s (%d)", f.Text, f.Count)
+	}
+	return strings.Join(parts, ", ")
+}
+
+type statsJSON struct {
+	Input         string      `json:"input,omitempty"`
+	Output        string      `json:"output,omitempty"`
+	Lines         int         `json:"lines"`
+	Words         int         `json:"words"`
+	DoubleLetters int         `json:"double_letters"`
+	TopWords      []Frequency `json:"top_words"`
+	TopLetters    []Frequency `json:"top_letters"`
+	Diffs         []LineDiff  `json:"diffs,omitempty"`
+	ChangedLines  int         `json:"changed_lines,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+func (s *Stats) toJSON() statsJSON {
+	return statsJSON{
+		Input: s.Input, Output: s.Output,
+		Lines: s.Lines, Words: s.Words, DoubleLetters: s.DoubleLetters,
+		TopWords: s.TopWords(topCount), TopLetters: s.TopLetters(topCount),
+		Diffs: s.Diffs, ChangedLines: s.ChangedLines,
+	}
+}
+
+// WriteJSONReport writes the stats of every file and their total as one
+// JSON document. failures maps inputs to the errors that stopped them.
+func WriteJSONReport(w io.Writer, files []*Stats, failures map[string]error) error {
+	report := struct {
+		Files []statsJSON `json:"files"`
+		Total statsJSON   `json:"total"`
+	}{Files: []statsJSON{}}
+
+	total := newStats()
+	for _, s := range files {
+		entry := s.toJSON()
+		if err, ok := failures[s.Input]; ok {
+			entry.Error = err.Error()
+		}
+		report.Files = append(report.Files, entry)
+		total.merge(s)
+	}
+	total.Diffs = nil
+	report.Total = total.toJSON()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(report)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultSplit  = " ,"
+	defaultJoiner = "-"
+)
+
+// Token is a word or the text between two words.
+type Token struct {
+	Text string
+	Word bool
+}
+
+// Tokenizer splits lines into words and separators and joins them back.
+//
+// In the legacy mode words are the runs of characters not in Split; the
+// separators between them are replaced with Joiner and leading and trailing
+// separators are dropped, as the original program did.
+//
+// In the Preserve mode words follow Unicode word boundaries: letters, digits
+// and combining marks, with apostrophes between letters ("п'ять", "don't")
+// and points or commas between digits ("3.14") kept inside the word. All
+// other text is kept as it was, except that a non-empty Joiner replaces
+// separators made only of Split characters.
+type Tokenizer struct {
+	Split    string
+	Joiner   string
+	Preserve bool
+	// WordChars lists extra characters that belong to words, such as the
+	// escapes written by a reversible double step.
+	WordChars string
+	// EscapeJoiner makes Join write a backslash before every backslash and
+	// Joiner character inside a word, and UnescapeSplit makes Tokenize read
+	// such escapes back as part of the word. Together they let the legacy
+	// mode decode words that contain the joiner, such as "будь-ласка".
+	EscapeJoiner  bool
+	UnescapeSplit bool
+}
+
+var legacyTokenizer = &Tokenizer{Split: defaultSplit, Joiner: defaultJoiner}
+
+func (t *Tokenizer) Tokenize(line string) []Token {
+	var tokens []Token
+	runes := []rune(line)
+	var escaped []bool
+	if t.UnescapeSplit {
+		runes, escaped = unescapeRunes(runes)
+	}
+	inWord := func(i int) bool {
+		return escaped != nil && escaped[i] || t.inWord(runes, i)
+	}
+	for i := 0; i < len(runes); {
+		word := inWord(i)
+		j := i + 1
+		for j < len(runes) && inWord(j) == word {
+			j++
+		}
+		tokens = append(tokens, Token{Text: string(runes[i:j]), Word: word})
+		i = j
+	}
+	return tokens
+}
+
+// inWord reports whether runes[i] belongs to a word.
+func (t *Tokenizer) inWord(runes []rune, i int) bool {
+	r := runes[i]
+	if strings.ContainsRune(t.WordChars, r) {
+		return true
+	}
+	if !t.Preserve {
+		return !strings.ContainsRune(t.Split, r)
+	}
+	if isWordRune(r) {
+		return true
+	}
+	if i == 0 || i+1 == len(runes) {
+		return false
+	}
+	prev, next := runes[i-1], runes[i+1]
+	switch {
+	case strings.ContainsRune("'’ʼ", r):
+		return unicode.IsLetter(prev) && unicode.IsLetter(next)
+	case r == '.' || r == ',':
+		return unicode.IsDigit(prev) && unicode.IsDigit(next)
+	}
+	return false
+}
+
+// unescapeRunes drops the backslashes written by EscapeJoiner and marks
+// the characters they escaped.
+func unescapeRunes(runes []rune) ([]rune, []bool) {
+	out := make([]rune, 0, len(runes))
+	escaped := make([]bool, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+			out, escaped = append(out, runes[i]), append(escaped, true)
+			continue
+		}
+		out, escaped = append(out, runes[i]), append(escaped, false)
+	}
+	return out, escaped
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// Join assembles the tokens into a line.
+func (t *Tokenizer) Join(tokens []Token) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		switch {
+		case token.Word && t.EscapeJoiner:
+			for _, r := range token.Text {
+				if r == '\\' || strings.ContainsRune(t.Joiner, r) {
+					b.WriteRune('\\')
+				}
+				b.WriteRune(r)
+			}
+		case token.Word:
+			b.WriteString(token.Text)
+		case !t.Preserve:
+			if i > 0 && i < len(tokens)-1 {
+				b.WriteString(t.Joiner)
+			}
+		case t.Joiner != "" && onlyRunesOf(token.Text, t.Split):
+			b.WriteString(t.Joiner)
+		default:
+			b.WriteString(token.Text)
+		}
+	}
+	return b.String()
+}
+
+func onlyRunesOf(s, set string) bool {
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if !strings.ContainsRune(set, r) {
+			return false
+		}
+		s = s[size:]
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Transform changes a single word. Transforms are chained into a Pipeline.
+type Transform interface {
+	Name() string
+	Apply(word string) string
+}
+
+// Pipeline applies its transforms to a word in order.
+type Pipeline []Transform
+
+// StepConfig names a transform and its parameters, as given on the command
+// line ("caesar:shift=3") or in a config file.
+type StepConfig struct {
+	Name   string `json:"name"`
+	Params Params `json:"params"`
+}
+
+type transformFactory struct {
+	description string
+	create      func(p *paramReader) (Transform, error)
+}
+
+var transformFactories = map[string]transformFactory{
+	"double": {"замінює подвоєні літери на '+'; параметр escape робить крок оборотним", func(p *paramReader) (Transform, error) {
+		escape, err := p.bool("escape", false)
+		if err != nil {
+			return nil, err
+		}
+		return doubleTransform{escape: escape}, nil
+	}},
+	"shuffle": {"перемішує літери слова; параметри seed (відтворюваність) і key (оборотність)", func(p *paramReader) (Transform, error) {
+		s := shuffleTransform{}
+		if p.text("seed", "") != "" {
+			seed, err := p.uint64("seed")
+			if err != nil {
+				return nil, err
+			}
+			s.seed, s.seeded = seed, true
+		}
+		if key := p.text("key", ""); key != "" {
+			s.key = []byte(key)
+		}
+		return s, nil
+	}},
+	"reverse": {"записує слово задом наперед", func(p *paramReader) (Transform, error) {
+		return invertibleFunc{wordFunc{"reverse", reverseString}, reverseString}, nil
+	}},
+	"upper": {"переводить слово у верхній регістр", func(p *paramReader) (Transform, error) {
+		return wordFunc{"upper", strings.ToUpper}, nil
+	}},
+	"lower": {"переводить слово у нижній регістр", func(p *paramReader) (Transform, error) {
+		return wordFunc{"lower", strings.ToLower}, nil
+	}},
+	"caesar": {"зсуває літери за абеткою; параметр shift (типово 3)", func(p *paramReader) (Transform, error) {
+		shift, err := p.int("shift", 3)
+		if err != nil {
+			return nil, err
+		}
+		return caesarTransform{shift: shift}, nil
+	}},
+	"runs":     {"замінює повтори літер за шаблоном (min, max, ignorecase, template) або кодує їх (mode=rle|unrle)", newRunsTransform},
+	"vigenere": {"шифр Віженера; параметр key", newVigenereTransform},
+	"atbash": {"шифр Атбаш: дзеркально відображає абетку", func(p *paramReader) (Transform, error) {
+		return atbashTransform{}, nil
+	}},
+	"railfence": {"шифр огорожі для кожного слова; параметр rails (типово 3)", newRailFenceTransform},
+	"leet": {"замінює літери схожими цифрами (leetspeak)", func(p *paramReader) (Transform, error) {
+		return wordFunc{"leet", leetspeak}, nil
+	}},
+	"novowels": {"видаляє голосні", func(p *paramReader) (Transform, error) {
+		return wordFunc{"novowels", removeVowels}, nil
+	}},
+}
+
+func (p Pipeline) Apply(word string) string {
+	for _, t := range p {
+		word = t.Apply(word)
+	}
+	return word
+}
+
+// ParsePipeline parses a comma-separated list of steps, each optionally
+// followed by colon-separated parameters: "double,shuffle,caesar:shift=3".
+func ParsePipeline(spec string) ([]StepConfig, error) {
+	var steps []StepConfig
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		step := StepConfig{Name: strings.TrimSpace(fields[0]), Params: Params{}}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("параметр %q кроку %q має бути у формі ключ=значення", field, step.Name)
+			}
+			step.Params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// BuildPipeline creates the transforms for the steps, rejecting unknown
+// transforms and parameters.
+func BuildPipeline(steps []StepConfig) (Pipeline, error) {
+	var pipeline Pipeline
+	for _, step := range steps {
+		factory, ok := transformFactories[step.Name]
+		if !ok {
+			return nil, fmt.Errorf("невідоме перетворення %q (доступні: %s)", step.Name, strings.Join(transformNames(), ", "))
+		}
+		params := &paramReader{step: step.Name, params: step.Params, used: map[string]bool{}}
+		t, err := factory.create(params)
+		if err != nil {
+			return nil, err
+		}
+		if err := params.checkUnused(); err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, t)
+	}
+	return pipeline, nil
+}
+
+func transformNames() []string {
+	names := make([]string, 0, len(transformFactories))
+	for name := range transformFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type paramReader struct {
+	step   string
+	params Params
+	used   map[string]bool
+}
+
+func (p *paramReader) text(key, fallback string) string {
+	p.used[key] = true
+	if value, ok := p.params[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+func (p *paramReader) int(key string, fallback int) (int, error) {
+	value := p.text(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("параметр %s кроку %q має бути цілим числом, отримано %q", key, p.step, value)
+	}
+	return n, nil
+}
+
+func (p *paramReader) uint64(key string) (uint64, error) {
+	value := p.text(key, "")
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("параметр %s кроку %q має бути невід'ємним цілим числом, отримано %q", key, p.step, value)
+	}
+	return n, nil
+}
+
+func (p *paramReader) bool(key string, fallback bool) (bool, error) {
+	value := p.text(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("параметр %s кроку %q має бути true або false, отримано %q", key, p.step, value)
+	}
+	return b, nil
+}
+
+func (p *paramReader) checkUnused() error {
+	for key := range p.params {
+		if !p.used[key] {
+			return fmt.Errorf("крок %q не має параметра %q", p.step, key)
+		}
+	}
+	return nil
+}
+
+// wordFunc adapts a plain string function to a Transform.
+type wordFunc struct {
+	name string
+	fn   func(string) string
+}
+
+func (w wordFunc) Name() string             { return w.name }
+func (w wordFunc) Apply(word string) string { return w.fn(word) }
+
+type caesarTransform struct {
+	shift int
+}
+
+func (c caesarTransform) Name() string { return "caesar" }
+
+func (c caesarTransform) Apply(word string) string {
+	runes := []rune(word)
+	for i, r := range runes {
+		runes[i] = shiftLetter(r, c.shift)
+	}
+	return string(runes)
+}
+
+var (
+	latinAlphabet     = []rune("abcdefghijklmnopqrstuvwxyz")
+	ukrainianAlphabet = []rune("абвгґдеєжзиіїйклмнопрстуфхцчшщьюя")
+)
+
+// shiftLetter moves a Latin or Ukrainian letter within its alphabet,
+// keeping its case; other characters are returned unchanged.
+func shiftLetter(r rune, shift int) rune {
+	alphabet, i, ok := letterIndex(r)
+	if !ok {
+		return r
+	}
+	n := len(alphabet)
+	return withCaseOf(alphabet[((i+shift)%n+n)%n], r)
+}
+
+func reverseString(input string) string {
+	letters := graphemes(input)
+	slices.Reverse(letters)
+	return strings.Join(letters, "")
+}
+
+var leetReplacer = strings.NewReplacer(
+	"a", "4", "A", "4", "e", "3", "E", "3", "i", "1", "I", "1",
+	"o", "0", "O", "0", "s", "5", "S", "5", "t", "7", "T", "7",
+	"а", "4", "А", "4", "е", "3", "Е", "3", "і", "1", "І", "1",
+	"о", "0", "О", "0", "з", "3", "З", "3", "б", "6", "Б", "6",
+)
+
+func leetspeak(input string) string {
+	return leetReplacer.Replace(input)
+}
+
+// removeVowels drops vowels together with their combining marks.
+func removeVowels(input string) string {
+	var b strings.Builder
+	for _, letter := range graphemes(input) {
+		if !strings.ContainsRune("aeiouyаеєиіїоуюя", unicode.ToLower(firstRune(letter))) {
+			b.WriteString(letter)
+		}
+	}
+	return b.String()
+}
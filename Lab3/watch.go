@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// fileState is what watch mode compares to notice that a file changed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statJobs(jobs []fileJob) map[string]fileState {
+	states := make(map[string]fileState, len(jobs))
+	for _, job := range jobs {
+		if info, err := os.Stat(job.Input); err == nil {
+			states[job.Input] = fileState{info.ModTime(), info.Size()}
+		}
+	}
+	return states
+}
+
+// watchJobs polls the inputs every poll interval until interrupted. The
+// inputs are planned again each time, so new files in watched directories
+// are picked up. A changed file is processed once it has stayed unchanged
+// for the debounce interval, which keeps a file that is still being saved
+// from being processed several times. Outputs of changed files are always
+// overwritten.
+func watchJobs(jobs []fileJob, plan func() ([]fileJob, error), run func([]fileJob, ExistingPolicy) int, poll, debounce time.Duration, out io.Writer) error {
+	for _, job := range jobs {
+		if job.Input == stdioPath {
+			return errors.New("режим -watch не працює зі stdin")
+		}
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	watch(jobs, plan, run, poll, debounce, out, interrupt)
+	return nil
+}
+
+// watch is the polling loop of watchJobs; it returns once stop receives.
+func watch(jobs []fileJob, plan func() ([]fileJob, error), run func([]fileJob, ExistingPolicy) int, poll, debounce time.Duration, out io.Writer, stop <-chan os.Signal) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	known := statJobs(jobs)
+	changed := map[string]time.Time{}
+	var planErr string
+	fmt.Fprintln(out, "Стежу за змінами, Ctrl+C — вихід.")
+	for {
+		select {
+		case <-stop:
+			fmt.Fprintln(out, "Спостереження зупинено.")
+			return
+		case now := <-ticker.C:
+			current, err := plan()
+			if err != nil {
+				// Report a missing input once, not on every poll.
+				if err.Error() != planErr {
+					fmt.Fprintln(out, "Помилка вибору файлів:", err)
+					planErr = err.Error()
+				}
+				continue
+			}
+			planErr = ""
+
+			states := statJobs(current)
+			for path, state := range states {
+				if previous, ok := known[path]; !ok || previous != state {
+					changed[path] = now
+				}
+			}
+			known = states
+
+			var ready []fileJob
+			for _, job := range current {
+				if since, ok := changed[job.Input]; ok && now.Sub(since) >= debounce {
+					ready = append(ready, job)
+					delete(changed, job.Input)
+				}
+			}
+			if len(ready) > 0 {
+				fmt.Fprintf(out, "[%s] Змінено файлів: %d\n", now.Format("15:04:05"), len(ready))
+				run(ready, Overwrite)
+			}
+		}
+	}
+}
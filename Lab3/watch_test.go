@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchProcessesOnlyChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	var jobs []fileJob
+	for _, name := range []string{"a.txt", "b.txt"} {
+		input := filepath.Join(dir, name)
+		if err := os.WriteFile(input, []byte("текст"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, fileJob{Input: input, Output: input + ".out"})
+	}
+
+	var mu sync.Mutex
+	var runs [][]fileJob
+	run := func(ready []fileJob, policy ExistingPolicy) int {
+		if policy != Overwrite {
+			t.Errorf("changed files run with policy %v", policy)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, ready)
+		return 0
+	}
+	plan := func() ([]fileJob, error) { return jobs, nil }
+
+	stop := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		watch(jobs, plan, run, 5*time.Millisecond, 20*time.Millisecond, io.Discard, stop)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(jobs[0].Input, []byte("інший текст"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the run and a few more polls that must not process anything.
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		mu.Lock()
+		n := len(runs)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	stop <- os.Interrupt
+	<-done
+
+	if want := [][]fileJob{jobs[:1]}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("got runs %v, want %v", runs, want)
+	}
+}